@@ -0,0 +1,53 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// ClusterGithubSecret is the Schema for the clustergithubsecrets API. It has the same spec as the
+// GithubSecret but is cluster scoped, for the secrets owned by the platform instead of a namespace.
+// Kubernetes secret references need a namespace.
+type ClusterGithubSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GithubSecretSpec   `json:"spec,omitempty"`
+	Status GithubSecretStatus `json:"status,omitempty"`
+}
+
+func (s *ClusterGithubSecret) GetSpec() *GithubSecretSpec {
+	return &s.Spec
+}
+
+func (s *ClusterGithubSecret) GetStatus() *GithubSecretStatus {
+	return &s.Status
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterGithubSecretList contains a list of ClusterGithubSecret
+type ClusterGithubSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGithubSecret `json:"items"`
+}
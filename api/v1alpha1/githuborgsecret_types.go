@@ -0,0 +1,93 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	OrgSecretVisibilityAll      string = "all"
+	OrgSecretVisibilityPrivate  string = "private"
+	OrgSecretVisibilitySelected string = "selected"
+)
+
+// GithubOrgSecretSpec defines the desired state of GithubOrgSecret
+type GithubOrgSecretSpec struct {
+	// Organization the secrets are stored in. Defaults to the owner configured for the operator.
+	Organization      string       `json:"organization,omitempty"`
+	ActionsSecrets    OrgSecrets   `json:"actionsSecrets,omitempty"`
+	DependaBotSecrets OrgSecrets   `json:"dependaBotSecrets,omitempty"`
+	CodespacesSecrets OrgSecrets   `json:"codespacesSecrets,omitempty"`
+	Variables         OrgVariables `json:"variables,omitempty"`
+	// ConflictPolicy controls what happens to a secret that already exists in the organization but
+	// wasn't pushed by the operator, see the GithubSecret spec.
+	//+kubebuilder:validation:Enum=Adopt;Overwrite;Fail
+	//+kubebuilder:default=Overwrite
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+}
+
+type OrgSecrets struct {
+	// Visibility defines which repositories of the organization can access the secrets.
+	//+kubebuilder:validation:Enum=all;private;selected
+	//+kubebuilder:default=private
+	Visibility string `json:"visibility,omitempty"`
+	// SelectedRepositories are the names of the repositories that can access the secrets
+	// if the visibility is set to selected.
+	SelectedRepositories []string  `json:"selectedRepositories,omitempty"`
+	Secrets              []Secrets `json:"secrets"`
+}
+
+type OrgVariables struct {
+	// Visibility defines which repositories of the organization can access the variables.
+	//+kubebuilder:validation:Enum=all;private;selected
+	//+kubebuilder:default=private
+	Visibility string `json:"visibility,omitempty"`
+	// SelectedRepositories are the names of the repositories that can access the variables
+	// if the visibility is set to selected.
+	SelectedRepositories []string   `json:"selectedRepositories,omitempty"`
+	Variables            []Variable `json:"variables"`
+}
+
+// GithubOrgSecretStatus defines the observed state of GithubOrgSecret
+type GithubOrgSecretStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Secrets records the source versions of the secrets the operator pushed to Github.
+	Secrets []SecretStatus `json:"secrets,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// GithubOrgSecret is the Schema for the githuborgsecrets API
+type GithubOrgSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GithubOrgSecretSpec   `json:"spec,omitempty"`
+	Status GithubOrgSecretStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GithubOrgSecretList contains a list of GithubOrgSecret
+type GithubOrgSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GithubOrgSecret `json:"items"`
+}
@@ -30,7 +30,17 @@ const (
 	ReconciliationFailedReason           string = "ReconciliationFailed"
 	ConditionTypeGithubTokenMissing      string = "GithubTokenMissing"
 	ConditionTypeGCPSecretManagerError   string = "GCPSecretManagerError"
-	ConditionTypeGithubActionSecretError string = "GithubActionSecretError "
+	ConditionTypeKubernetesSecretError   string = "KubernetesSecretError"
+	ConditionTypeVaultError              string = "VaultError"
+	ConditionTypeAWSError                string = "AWSError"
+	ConditionTypeAzureKeyVaultError      string = "AzureKeyVaultError"
+	ConditionTypeSOPSError               string = "SOPSError"
+	ConditionTypeTemplateError           string = "TemplateError"
+	ConditionTypeSecretPropertyError     string = "SecretPropertyError"
+	ConditionTypeGeneratedSecretError    string = "GeneratedSecretError"
+	ConditionTypeSecretConflict          string = "SecretConflict"
+	ConditionTypeUnknownSecretSource     string = "UnknownSecretSource"
+	ConditionTypeGithubActionSecretError string = "GithubActionSecretError"
 	ConditionTypeReady                   string = "Ready"
 )
 
@@ -42,33 +52,247 @@ type GithubSecretSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Foo is an example field of GithubSecret. Edit githubsecret_types.go to remove/update
-	Repository        string            `json:"repository"`
-	DependaBotSecrets DependaBotSecrets `json:"dependaBotSecrets,omitempty"`
+	// Owner is the user or organization the repositories belong to. It defaults to the owner the
+	// operator is configured with.
+	Owner string `json:"owner,omitempty"`
+	// Repository the secrets and variables are stored in. A repository of another owner can be
+	// given as owner/repository.
+	Repository string `json:"repository,omitempty"`
+	// Repositories the secrets and variables are stored in, in addition to the repository. Every
+	// repository is reconciled on its own, a failure in one of them doesn't block the others.
+	Repositories []string `json:"repositories,omitempty"`
+	// RepositorySelector selects repositories of the owner in addition to the repository and repositories.
+	// The repositories are enumerated on every resync, so new repositories are picked up automatically.
+	RepositorySelector *RepositorySelector `json:"repositorySelector,omitempty"`
+	DependaBotSecrets  DependaBotSecrets   `json:"dependaBotSecrets,omitempty"`
+	ActionsSecrets     ActionsSecrets      `json:"actionsSecrets,omitempty"`
+	CodespacesSecrets  CodespacesSecrets   `json:"codespacesSecrets,omitempty"`
+	// Environments maps the name of a deployment environment to its secrets and variables.
+	// Missing environments are created in the repository.
+	Environments map[string]Environment `json:"environments,omitempty"`
+	Variables    []Variable             `json:"variables,omitempty"`
+	// DeletionPolicy controls what happens when the GithubSecret is deleted. Delete removes the secrets
+	// and variables the operator pushed from Github, Retain keeps them and Orphan
+	// additionally keeps the Kubernetes Secrets of the generated values, which are otherwise garbage
+	// collected together with the GithubSecret.
+	//+kubebuilder:validation:Enum=Delete;Retain;Orphan
+	//+kubebuilder:default=Delete
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Prune removes the secrets from Github that were removed from the spec. Only the secrets recorded
+	// in the status are removed, secrets that were created manually in the repository are left alone.
+	Prune bool `json:"prune,omitempty"`
+	// ConflictPolicy controls what happens to a secret that already exists in Github but wasn't pushed
+	// by the operator. Its value is unknown, so Adopt and Overwrite push the value of the source once,
+	// Adopt additionally records it as adopted. Fail leaves it alone and reports the SecretConflict
+	// condition. Secrets can override it.
+	//+kubebuilder:validation:Enum=Adopt;Overwrite;Fail
+	//+kubebuilder:default=Overwrite
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+	// PruneAdopted also removes the adopted secrets when pruning or deleting the custom resource.
+	// They were created manually, so they're left alone by default.
+	PruneAdopted bool `json:"pruneAdopted,omitempty"`
+}
+
+const (
+	DeletionPolicyDelete string = "Delete"
+	DeletionPolicyRetain string = "Retain"
+	DeletionPolicyOrphan string = "Orphan"
+)
+
+const (
+	ConflictPolicyAdopt     string = "Adopt"
+	ConflictPolicyOverwrite string = "Overwrite"
+	ConflictPolicyFail      string = "Fail"
+)
+
+// RepositorySelector selects the repositories of the owner that match all of its criteria.
+type RepositorySelector struct {
+	// Topics the repositories are tagged with, a repository has to have all of them.
+	Topics []string `json:"topics,omitempty"`
+	// Name is a regular expression the name of the repositories has to match.
+	Name string `json:"name,omitempty"`
+	// Visibility of the repositories, public, private or internal.
+	//+kubebuilder:validation:Enum=public;private;internal
+	Visibility string `json:"visibility,omitempty"`
+	// Archived selects the archived repositories instead of the ones that aren't archived.
+	// Archived repositories are read-only, so they're skipped by default.
+	Archived bool `json:"archived,omitempty"`
 }
 
 type Secrets struct {
-	Key  string `json:"key"`
-	Name string `json:"name"`
+	Name      string `json:"name"`
+	SecretRef `json:",inline"`
+	// Template is a Go text/template that renders the value of the secret from the inputs,
+	// which are available as {{ .<name> }}. The secret reference isn't read if it's set.
+	// Besides the builtin functions b64enc, b64dec and toJson can be used.
+	Template string `json:"template,omitempty"`
+	// Inputs maps the names used in the template to the secrets they're read from.
+	Inputs map[string]SecretRef `json:"inputs,omitempty"`
+	// ConflictPolicy overrides the conflict policy of the custom resource for this secret.
+	//+kubebuilder:validation:Enum=Adopt;Overwrite;Fail
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+}
+
+const (
+	// SecretSourceGCP reads the secret from GCP Secret Manager.
+	SecretSourceGCP string = "GCP"
+	// SecretSourceKubernetes reads the secret from a key of a Kubernetes Secret.
+	SecretSourceKubernetes string = "Kubernetes"
+	// SecretSourceVault reads the secret from a KV secrets engine of HashiCorp Vault.
+	SecretSourceVault string = "Vault"
+	// SecretSourceAWSSecretsManager reads the secret from AWS Secrets Manager.
+	SecretSourceAWSSecretsManager string = "AWSSecretsManager"
+	// SecretSourceAWSParameterStore reads the secret from a parameter of the AWS SSM Parameter Store.
+	SecretSourceAWSParameterStore string = "AWSParameterStore"
+	// SecretSourceAzureKeyVault reads the secret from an Azure Key Vault.
+	SecretSourceAzureKeyVault string = "AzureKeyVault"
+	// SecretSourceSOPS decrypts the secret from a SOPS encrypted document.
+	SecretSourceSOPS string = "SOPS"
+	// SecretSourceGenerated generates a random password or keypair and stores it in a Kubernetes Secret.
+	SecretSourceGenerated string = "Generated"
+)
+
+const (
+	GeneratedTypePassword string = "Password"
+	GeneratedTypeEd25519  string = "Ed25519"
+	GeneratedTypeRSA      string = "RSA"
+)
+
+// SecretRef references a value stored in one of the secret sources.
+type SecretRef struct {
+	// Key of the secret in the source. For GCP it can also be the full
+	// projects/<project>/secrets/<secret>[/versions/<version>] resource name.
+	// For Kubernetes it's the key in the data of the Kubernetes Secret, for Vault the path of
+	// the secret including the mount of the KV secrets engine, for example secret/my-app.
+	// For AWS and Azure it's the name of the secret or parameter, for SOPS the top level key
+	// of the value in the decrypted document. It's not used by secrets rendered from a template.
+	Key string `json:"key,omitempty"`
+	// Source of the secret, GCP, Kubernetes, Vault, AWSSecretsManager, AWSParameterStore, AzureKeyVault, SOPS or Generated.
 	//+kubebuilder:default="GCP"
 	Source string `json:"source"`
+	// Version of the secret, either a version number or an alias. Defaults to the latest version.
+	// Vault only supports version numbers of KV version 2 secrets, AWSSecretsManager version ids
+	// and staging labels, AWSParameterStore version numbers and labels, AzureKeyVault version ids.
+	// Pinning the version allows to stage a new version in the source and promote it by changing the CR.
+	Version string `json:"version,omitempty"`
+	// Project of the GCP secret. Defaults to the project configured for the operator.
+	Project string `json:"project,omitempty"`
+	// Property of a structured JSON or YAML secret, for example of a GCP service account key or a Vault
+	// secret with multiple values. It's either a top level key or a JSONPath expression like
+	// $.database.password or {.keys[0].id}. The whole secret is used if it's empty.
+	Property string `json:"property,omitempty"`
+	// Region of the AWS secret. Defaults to the region configured for the operator.
+	Region string `json:"region,omitempty"`
+	// VaultURL of the Azure Key Vault, for example https://my-vault.vault.azure.net.
+	VaultURL string `json:"vaultURL,omitempty"`
+	// SecretName is the name of the Kubernetes Secret of the Kubernetes source, or the one the
+	// Generated source stores the generated values in.
+	SecretName string `json:"secretName,omitempty"`
+	// Namespace of the Kubernetes Secret or ConfigMap. Only used by cluster scoped resources,
+	// namespaced resources always read the Kubernetes Secrets and ConfigMaps of their own namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Encrypted is a SOPS encrypted YAML or JSON document of the SOPS source.
+	Encrypted string `json:"encrypted,omitempty"`
+	// ConfigMapName is the name of the ConfigMap with the SOPS encrypted document,
+	// used by the SOPS source if the document isn't set in encrypted.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// ConfigMapKey is the key of the SOPS encrypted document in the ConfigMap.
+	ConfigMapKey string `json:"configMapKey,omitempty"`
+	// Generate configures the value created by the Generated source, a random password by default.
+	Generate *GeneratedSecret `json:"generate,omitempty"`
+}
+
+// GeneratedSecret configures a value generated by the operator. The value is generated once and
+// stored in a Kubernetes Secret owned by the custom resource, changes of the other fields don't
+// generate it again.
+type GeneratedSecret struct {
+	// Type of the value, a Password or an Ed25519 or RSA keypair. The private key of a keypair is
+	// stored in OpenSSH format under the key, the public key in authorized_keys format under <key>.pub.
+	//+kubebuilder:validation:Enum=Password;Ed25519;RSA
+	Type string `json:"type,omitempty"`
+	// Length of the password, defaults to 32.
+	//+kubebuilder:validation:Minimum=1
+	Length int `json:"length,omitempty"`
+	// Charset are the characters the password is made of, defaults to letters and digits.
+	Charset string `json:"charset,omitempty"`
+	// Bits of the RSA key, defaults to 4096.
+	//+kubebuilder:validation:Minimum=1
+	Bits int `json:"bits,omitempty"`
+	// Regenerate generates the value again whenever it changes, for example set it to the current date.
+	Regenerate string `json:"regenerate,omitempty"`
 }
 
 type DependaBotSecrets struct {
 	Secrets []Secrets `json:"secrets"`
 }
 
+type ActionsSecrets struct {
+	Secrets []Secrets `json:"secrets"`
+}
+
+type CodespacesSecrets struct {
+	Secrets []Secrets `json:"secrets"`
+}
+
+type Environment struct {
+	Secrets   []Secrets  `json:"secrets,omitempty"`
+	Variables []Variable `json:"variables,omitempty"`
+}
+
+// Variable is a plaintext Github Actions configuration variable.
+type Variable struct {
+	Name string `json:"name"`
+	// Value is the literal value of the variable.
+	Value string `json:"value,omitempty"`
+	// ValueFrom reads the value of the variable from a secret source instead.
+	ValueFrom *SecretRef `json:"valueFrom,omitempty"`
+}
+
 // GithubSecretStatus defines the observed state of GithubSecret
 type GithubSecretStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Secrets records the source versions of the secrets the operator pushed to Github.
+	// Deprecated: the secrets are recorded per repository, it's only read to migrate older resources.
+	Secrets []SecretStatus `json:"secrets,omitempty"`
+	// Repositories reports the result of the latest reconcile pass for every repository.
+	Repositories []RepositoryStatus `json:"repositories,omitempty"`
+}
+
+// RepositoryStatus reports the result of the latest reconcile pass for one repository
+// together with the secrets the operator pushed to it.
+type RepositoryStatus struct {
+	Name string `json:"name"`
+	// Conditions report the failures of the repository and whether it's ready.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Secrets records the source versions of the secrets the operator pushed to the repository.
+	Secrets []SecretStatus `json:"secrets,omitempty"`
+	// Variables records the variables the operator created or updated in the repository.
+	Variables []VariableStatus `json:"variables,omitempty"`
+}
+
+// VariableStatus records a variable the operator wrote, only those are removed by the Delete policy.
+type VariableStatus struct {
+	Name string `json:"name"`
+	// Kind of the variable, Repository or Environment <name> for environment variables.
+	Kind string `json:"kind"`
 }
 
 type GithubSecreOperatorStatus struct {
 }
 
+// SecretStatus records the version of a secret's source value the operator last pushed to Github.
+// A secret is pushed again as soon as its source version changes.
 type SecretStatus struct {
+	Name string `json:"name"`
+	// Kind of the secret, DependaBot, Actions, Codespaces or Environment <name> for environment secrets.
+	Kind    string `json:"kind"`
+	Version string `json:"version,omitempty"`
+	// LastUpdated is the time the operator pushed the secret to Github.
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+	// Adopted is set if the secret already existed in Github when it was adopted by the operator.
+	Adopted bool `json:"adopted,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -83,6 +307,14 @@ type GithubSecret struct {
 	Status GithubSecretStatus `json:"status,omitempty"`
 }
 
+func (s *GithubSecret) GetSpec() *GithubSecretSpec {
+	return &s.Spec
+}
+
+func (s *GithubSecret) GetStatus() *GithubSecretStatus {
+	return &s.Status
+}
+
 //+kubebuilder:object:root=true
 
 // GithubSecretList contains a list of GithubSecret
@@ -40,6 +40,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(GroupVersion,
 		&GithubSecret{},
 		&GithubSecretList{},
+		&GithubOrgSecret{},
+		&GithubOrgSecretList{},
+		&ClusterGithubSecret{},
+		&ClusterGithubSecretList{},
 	)
 	metav1.AddToGroupVersion(scheme, GroupVersion)
 	return nil
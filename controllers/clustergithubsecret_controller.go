@@ -0,0 +1,92 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+// ClusterGithubSecretReconciler reconciles a ClusterGithubSecret object with the same logic
+// as the GithubSecretReconciler.
+type ClusterGithubSecretReconciler struct {
+	GithubSecretReconciler
+}
+
+//+kubebuilder:rbac:groups=secret.fr123k.uk,resources=clustergithubsecrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=secret.fr123k.uk,resources=clustergithubsecrets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=secret.fr123k.uk,resources=clustergithubsecrets/finalizers,verbs=get;update;patch
+
+// Reconcile pushes the secrets of the ClusterGithubSecret to its repositories. Kubernetes secret
+// references of a cluster scoped resource need a namespace.
+func (r *ClusterGithubSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return r.reconcileObject(ctx, req, "ClusterGithubSecret", &secretv1alpha1.ClusterGithubSecret{})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterGithubSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &secretv1alpha1.ClusterGithubSecret{}, secretRefIndex, func(obj client.Object) []string {
+		instance := obj.(*secretv1alpha1.ClusterGithubSecret)
+		return kubernetesSecretRefs("", specSecretRefs(instance.Spec))
+	})
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &secretv1alpha1.ClusterGithubSecret{}, configMapRefIndex, func(obj client.Object) []string {
+		instance := obj.(*secretv1alpha1.ClusterGithubSecret)
+		return configMapRefs("", specSecretRefs(instance.Spec))
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&secretv1alpha1.ClusterGithubSecret{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsFor(secretRefIndex))).
+		Watches(&v1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.requestsFor(configMapRefIndex))).
+		Complete(r)
+}
+
+// requestsFor returns a function that maps a Kubernetes Secret or ConfigMap to the ClusterGithubSecrets
+// referencing it according to the index.
+func (r *ClusterGithubSecretReconciler) requestsFor(index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		instances := &secretv1alpha1.ClusterGithubSecretList{}
+		err := r.List(ctx, instances, client.MatchingFields{index: fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())})
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to list ClusterGithubSecrets referencing object", "object", obj.GetName())
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(instances.Items))
+		for _, instance := range instances.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&instance)})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+func TestReconcileClusterGithubSecret(t *testing.T) {
+	var pushed []string
+	key, keyID := "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=", "test_key_id"
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(mock.GetReposActionsSecretsByOwnerByRepo, gogithub.Secrets{}),
+		mock.WithRequestMatch(mock.GetReposActionsSecretsPublicKeyByOwnerByRepo, gogithub.PublicKey{Key: &key, KeyID: &keyID}),
+		mock.WithRequestMatchHandler(
+			mock.PutReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pushed = append(pushed, r.URL.Path)
+			}),
+		),
+	)
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, secretv1alpha1.AddToScheme(scheme))
+	instance := &secretv1alpha1.ClusterGithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repository: "test_repo",
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "registry", Namespace: "platform"}},
+			}},
+		},
+	}
+	registry := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "platform"}, Data: map[string][]byte{"token": []byte("value")}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, registry).WithStatusSubresource(instance).Build()
+
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceKubernetes, secretv1alpha1.ConditionTypeKubernetesSecretError, source.Kubernetes{Client: c, VersionKey: []byte("version-key")})
+	r := &ClusterGithubSecretReconciler{GithubSecretReconciler: GithubSecretReconciler{
+		Client:  c,
+		Scheme:  scheme,
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: sources,
+		Config:  config.Config{Owner: "fr123k"},
+	}}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "platform"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/repos/fr123k/test_repo/actions/secrets/TOKEN"}, pushed)
+
+	current := &secretv1alpha1.ClusterGithubSecret{}
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "platform"}, current))
+	assert.True(t, controllerutil.ContainsFinalizer(current, Finalizer))
+	assert.True(t, apimeta.IsStatusConditionTrue(current.Status.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.NotNil(t, findSecretStatus(current.Status.Repositories[0].Secrets, "Actions", "TOKEN"))
+}
+
+func TestOrphanGeneratedSecretsOfClusterGithubSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, secretv1alpha1.AddToScheme(scheme))
+	instance := &secretv1alpha1.ClusterGithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "platform", UID: "uid"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "WEBHOOK", SecretRef: secretv1alpha1.SecretRef{Key: "webhook", Source: secretv1alpha1.SecretSourceGenerated, SecretName: "generated", Namespace: "platform"}},
+			}},
+		},
+	}
+	generated := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "generated", Namespace: "platform"}}
+	assert.NoError(t, controllerutil.SetOwnerReference(instance, generated, scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, generated).Build()
+	r := &GithubSecretReconciler{Client: c, Scheme: scheme}
+
+	assert.NoError(t, r.orphanGeneratedSecrets(context.Background(), instance))
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "platform", Name: "generated"}, generated))
+	assert.Empty(t, generated.OwnerReferences)
+}
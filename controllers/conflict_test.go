@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+func TestReconcileSecretsConflictPolicy(t *testing.T) {
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"token": "value"})
+	r := &GithubSecretReconciler{Sources: sources}
+
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       secretv1alpha1.GithubSecretSpec{Repository: "test_repo", ConflictPolicy: secretv1alpha1.ConflictPolicyFail},
+	}
+
+	var pushed []string
+	target := secretTarget{
+		kind: "Actions",
+		secrets: []secretv1alpha1.Secrets{
+			{Name: "ADOPTED", SecretRef: secretv1alpha1.SecretRef{Key: "token"}, ConflictPolicy: secretv1alpha1.ConflictPolicyAdopt},
+			{Name: "OVERWRITTEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}, ConflictPolicy: secretv1alpha1.ConflictPolicyOverwrite},
+			{Name: "MANUAL", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+			{Name: "OTHER", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+			{Name: "NEW", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+		},
+		list: func(owner, repository string) (*github.Secrets, error) {
+			return &github.Secrets{Secrets: []*github.Secret{{Name: "ADOPTED"}, {Name: "OVERWRITTEN"}, {Name: "MANUAL"}, {Name: "OTHER"}}}, nil
+		},
+		add: func(owner, repository, name, value string) error {
+			pushed = append(pushed, name)
+			return nil
+		},
+	}
+
+	repo := &secretv1alpha1.RepositoryStatus{Name: "test_repo"}
+	_, err := r.reconcileSecrets(context.Background(), logr.Discard(), instance, repo, target)
+	assert.NoError(t, err)
+
+	// the value of the adopted secret is unknown, so it's pushed once as well
+	assert.Equal(t, []string{"ADOPTED", "OVERWRITTEN", "NEW"}, pushed)
+	assert.True(t, findSecretStatus(repo.Secrets, "Actions", "ADOPTED").Adopted)
+	assert.False(t, findSecretStatus(repo.Secrets, "Actions", "OVERWRITTEN").Adopted)
+	assert.Nil(t, findSecretStatus(repo.Secrets, "Actions", "MANUAL"))
+
+	condition := apimeta.FindStatusCondition(repo.Conditions, secretv1alpha1.ConditionTypeSecretConflict)
+	assert.Equal(t, "Actions secret MANUAL already exists in Github and wasn't pushed by the operator; "+
+		"Actions secret OTHER already exists in Github and wasn't pushed by the operator", condition.Message)
+
+	// the recorded secrets aren't pushed again and stay adopted, NEW is still missing from the listed secrets
+	pushed = nil
+	_, err = r.reconcileSecrets(context.Background(), logr.Discard(), instance, repo, target)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"NEW"}, pushed)
+	assert.True(t, findSecretStatus(repo.Secrets, "Actions", "ADOPTED").Adopted)
+}
+
+func TestConflictPolicy(t *testing.T) {
+	secret := secretv1alpha1.Secrets{Name: "TOKEN"}
+	assert.Equal(t, secretv1alpha1.ConflictPolicyOverwrite, conflictPolicy("", secret))
+	assert.Equal(t, secretv1alpha1.ConflictPolicyFail, conflictPolicy(secretv1alpha1.ConflictPolicyFail, secret))
+
+	secret.ConflictPolicy = secretv1alpha1.ConflictPolicyOverwrite
+	assert.Equal(t, secretv1alpha1.ConflictPolicyOverwrite, conflictPolicy(secretv1alpha1.ConflictPolicyFail, secret))
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/github"
+)
+
+// reconcileDelete applies the deletion policy of the GithubSecret and removes the finalizer afterwards.
+// The finalizer is kept, and the deletion retried, as long as the policy can't be applied completely.
+func (r *GithubSecretReconciler) reconcileDelete(ctx context.Context, reqLogger logr.Logger, instance githubSecretObject) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, Finalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	var err error
+	switch instance.GetSpec().DeletionPolicy {
+	case secretv1alpha1.DeletionPolicyRetain:
+		reqLogger.Info("Retaining Github secrets of deleted GithubSecret")
+	case secretv1alpha1.DeletionPolicyOrphan:
+		err = r.orphanGeneratedSecrets(ctx, instance)
+	default:
+		err = r.finalize(reqLogger, instance)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("failed to apply deletion policy %s. Error:%s", instance.GetSpec().DeletionPolicy, err.Error())
+		reqLogger.Error(err, msg)
+		apimeta.SetStatusCondition(&instance.GetStatus().Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		updateErr := r.Status().Update(ctx, instance)
+		if updateErr != nil {
+			reqLogger.Error(updateErr, "Failed to update GithubSecrets status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	controllerutil.RemoveFinalizer(instance, Finalizer)
+	return reconcile.Result{}, r.Update(ctx, instance)
+}
+
+// finalize removes the secrets and variables of the spec from the repositories of the spec and the ones
+// recorded in the status. A failing repository doesn't stop the removal from the others, the failures
+// are returned together.
+func (r *GithubSecretReconciler) finalize(log logr.Logger, instance githubSecretObject) error {
+	repositories := specRepositories(*instance.GetSpec())
+	// the selected repositories are only known from the status
+	for _, repo := range instance.GetStatus().Repositories {
+		if !slices.Contains(repositories, repo.Name) {
+			repositories = append(repositories, repo.Name)
+		}
+	}
+
+	var errs []error
+	for _, repository := range repositories {
+		err := r.finalizeRepository(instance, repository)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Info("Successfully removed Github Secrets", "repository", repository)
+	}
+	return errors.Join(errs...)
+}
+
+// finalizeRepository removes the secrets and variables of the spec from the repository. Secrets that
+// have already been removed are skipped, the other failures are returned together.
+func (r *GithubSecretReconciler) finalizeRepository(instance githubSecretObject, repository string) error {
+	var errs []error
+	remove := func(err error, kind string, name string) {
+		if err != nil && !github.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to remove %s %s from repository %s. Error:%w", kind, name, repository, err))
+		}
+	}
+	// only the secrets the operator pushed are removed, the others belong to someone else. That includes
+	// the ones refused because of the Fail conflict policy and the ones whose source never could be read.
+	// Adopted secrets were created manually and are only removed if pruneAdopted is set.
+	secrets := repositorySecrets(*instance.GetStatus(), repository)
+	owner, repositoryName := r.repositoryOwner(*instance.GetSpec(), repository)
+	keep := func(kind string, secret secretv1alpha1.Secrets) bool {
+		status := findSecretStatus(secrets, kind, secret.Name)
+		return status == nil || status.Adopted && !instance.GetSpec().PruneAdopted
+	}
+
+	for _, v := range instance.GetSpec().DependaBotSecrets.Secrets {
+		if !keep("DependaBot", v) {
+			remove(r.Github.RemoveDependaBotSecrets(owner, repositoryName, v.Name), "DependaBot secret", v.Name)
+		}
+	}
+	for _, v := range instance.GetSpec().ActionsSecrets.Secrets {
+		if !keep("Actions", v) {
+			remove(r.Github.RemoveActionsSecrets(owner, repositoryName, v.Name), "Actions secret", v.Name)
+		}
+	}
+	for _, v := range instance.GetSpec().CodespacesSecrets.Secrets {
+		if !keep("Codespaces", v) {
+			remove(r.Github.RemoveCodespacesSecrets(owner, repositoryName, v.Name), "Codespaces secret", v.Name)
+		}
+	}
+	// variables the operator never wrote were created manually with the desired value
+	variables := repositoryVariables(*instance.GetStatus(), repository)
+	for _, v := range instance.GetSpec().Variables {
+		if findVariableStatus(variables, "Repository", v.Name) != nil {
+			remove(r.Github.RemoveRepoVariable(owner, repositoryName, v.Name), "variable", v.Name)
+		}
+	}
+	for environment, spec := range instance.GetSpec().Environments {
+		for _, v := range spec.Secrets {
+			if !keep(environmentKind(environment), v) {
+				remove(r.Github.RemoveEnvironmentSecrets(owner, repositoryName, environment, v.Name), environmentKind(environment)+" secret", v.Name)
+			}
+		}
+		for _, v := range spec.Variables {
+			if findVariableStatus(variables, environmentKind(environment), v.Name) != nil {
+				remove(r.Github.RemoveEnvironmentVariable(owner, repositoryName, environment, v.Name), environmentKind(environment)+" variable", v.Name)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// pruneSecrets removes the secrets recorded in the status that were removed from the spec from the
+// repository. The secrets that couldn't be removed are added to the desired ones, so their status
+// is retained and the removal is retried on the next pass.
+func (r *GithubSecretReconciler) pruneSecrets(reqLogger logr.Logger, instance githubSecretObject, repo *secretv1alpha1.RepositoryStatus, desired map[string]bool) {
+	repository := repo.Name
+	owner, repositoryName := r.repositoryOwner(*instance.GetSpec(), repository)
+	for _, secret := range repo.Secrets {
+		key := secretStatusKey(secret.Kind, secret.Name)
+		if desired[key] {
+			continue
+		}
+		if secret.Adopted && !instance.GetSpec().PruneAdopted {
+			// the secret was created manually, it's only forgotten
+			reqLogger.Info("kept adopted secret", "secret", secret.Name, "kind", secret.Kind, "repository", repository)
+			continue
+		}
+
+		err := r.removeSecret(owner, repositoryName, secret.Kind, secret.Name)
+		if err != nil && !github.IsNotFound(err) {
+			msg := fmt.Sprintf("failed to prune %s secret %s. Error:%s", secret.Kind, secret.Name, err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+			desired[key] = true
+			continue
+		}
+		reqLogger.Info("pruned secret", "secret", secret.Name, "kind", secret.Kind, "repository", repository)
+	}
+}
+
+// removeSecret removes the secret of the kind recorded in the status from the repository of the owner.
+func (r *GithubSecretReconciler) removeSecret(owner, repository string, kind string, name string) error {
+	switch kind {
+	case "DependaBot":
+		return r.Github.RemoveDependaBotSecrets(owner, repository, name)
+	case "Actions":
+		return r.Github.RemoveActionsSecrets(owner, repository, name)
+	case "Codespaces":
+		return r.Github.RemoveCodespacesSecrets(owner, repository, name)
+	}
+	if environment, ok := strings.CutPrefix(kind, environmentKind("")); ok {
+		return r.Github.RemoveEnvironmentSecrets(owner, repository, environment, name)
+	}
+	return fmt.Errorf("unknown secret kind %s", kind)
+}
+
+// orphanGeneratedSecrets removes the GithubSecret from the owners of the Kubernetes Secrets of its
+// generated values, so they aren't garbage collected and can be adopted by another custom resource.
+func (r *GithubSecretReconciler) orphanGeneratedSecrets(ctx context.Context, instance githubSecretObject) error {
+	for _, ref := range generatedSecretRefs(instance.GetNamespace(), specSecretRefs(*instance.GetSpec())) {
+		namespace, name, _ := strings.Cut(ref, "/")
+		secret := &v1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		owned, err := controllerutil.HasOwnerReference(secret.OwnerReferences, instance, r.Scheme)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			continue
+		}
+		err = controllerutil.RemoveOwnerReference(instance, secret, r.Scheme)
+		if err != nil {
+			return err
+		}
+		err = r.Update(ctx, secret)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generatedSecretRefs returns the Kubernetes Secrets the Generated source stores values in,
+// in the format <namespace>/<name>.
+func generatedSecretRefs(namespace string, refs []secretv1alpha1.SecretRef) []string {
+	return namespacedRefs(namespace, refs, func(ref secretv1alpha1.SecretRef) string {
+		if ref.Source != secretv1alpha1.SecretSourceGenerated {
+			return ""
+		}
+		return ref.SecretName
+	})
+}
@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+)
+
+// deletedGithubSecret returns a GithubSecret that is being deleted with the given policy.
+func deletedGithubSecret(policy string) *secretv1alpha1.GithubSecret {
+	now := metav1.Now()
+	return &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "uid", Finalizers: []string{Finalizer}, DeletionTimestamp: &now},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repository:     "test_repo",
+			DeletionPolicy: policy,
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+				{Name: "REMOVED", SecretRef: secretv1alpha1.SecretRef{Key: "removed"}},
+				{Name: "WEBHOOK", SecretRef: secretv1alpha1.SecretRef{Key: "webhook", Source: secretv1alpha1.SecretSourceGenerated, SecretName: "generated"}},
+			}},
+		},
+		Status: secretv1alpha1.GithubSecretStatus{Repositories: []secretv1alpha1.RepositoryStatus{
+			{Name: "test_repo", Secrets: []secretv1alpha1.SecretStatus{
+				{Kind: "Actions", Name: "TOKEN", Version: "1"},
+				{Kind: "Actions", Name: "REMOVED", Version: "1"},
+				{Kind: "Actions", Name: "WEBHOOK", Version: "1"},
+			}},
+		}},
+	}
+}
+
+func newDeletionReconciler(t *testing.T, instance *secretv1alpha1.GithubSecret, githubClient *http.Client) *GithubSecretReconciler {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, secretv1alpha1.AddToScheme(scheme))
+
+	generated := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "generated", Namespace: "default"}}
+	assert.NoError(t, controllerutil.SetOwnerReference(instance, generated, scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, generated).WithStatusSubresource(instance).Build()
+
+	return &GithubSecretReconciler{
+		Client: c,
+		Scheme: scheme,
+		Github: github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Config: config.Config{Owner: "fr123k"},
+	}
+}
+
+func TestReconcileDeleteRemovesSecrets(t *testing.T) {
+	var removed []string
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				removed = append(removed, r.URL.Path)
+				if r.URL.Path == "/repos/fr123k/test_repo/actions/secrets/REMOVED" {
+					mock.WriteError(w, http.StatusNotFound, "Not Found")
+				}
+			}),
+		),
+	)
+	instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyDelete)
+	r := newDeletionReconciler(t, instance, githubClient)
+
+	_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/repos/fr123k/test_repo/actions/secrets/TOKEN",
+		"/repos/fr123k/test_repo/actions/secrets/REMOVED",
+		"/repos/fr123k/test_repo/actions/secrets/WEBHOOK",
+	}, removed)
+	// the fake client deletes the object once the last finalizer is removed
+	err = r.Get(context.Background(), client.ObjectKeyFromObject(instance), &secretv1alpha1.GithubSecret{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestReconcileDeleteKeepsFinalizerOnFailure(t *testing.T) {
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mock.WriteError(w, http.StatusInternalServerError, "github went belly up or something")
+			}),
+		),
+	)
+	instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyDelete)
+	r := newDeletionReconciler(t, instance, githubClient)
+
+	_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+	assert.ErrorContains(t, err, "failed to remove Actions secret TOKEN")
+
+	current := &secretv1alpha1.GithubSecret{}
+	assert.NoError(t, r.Get(context.Background(), client.ObjectKeyFromObject(instance), current))
+	assert.Contains(t, current.Finalizers, Finalizer)
+	assert.Equal(t, secretv1alpha1.ConditionTypeGithubActionSecretError, current.Status.Conditions[0].Type)
+}
+
+func TestReconcileDeleteOrphan(t *testing.T) {
+	instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyOrphan)
+	// no Github calls are expected
+	r := newDeletionReconciler(t, instance, mock.NewMockedHTTPClient())
+
+	_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	generated := &v1.Secret{}
+	assert.NoError(t, r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "generated"}, generated))
+	assert.Empty(t, generated.OwnerReferences)
+}
+
+func TestReconcileDeleteRetain(t *testing.T) {
+	instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyRetain)
+	r := newDeletionReconciler(t, instance, mock.NewMockedHTTPClient())
+
+	_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	generated := &v1.Secret{}
+	assert.NoError(t, r.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "generated"}, generated))
+	assert.Len(t, generated.OwnerReferences, 1)
+}
+
+func TestPruneSecrets(t *testing.T) {
+	var removed []string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		removed = append(removed, r.Method+" "+r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/FAILING") {
+			mock.WriteError(w, http.StatusInternalServerError, "github went belly up or something")
+		}
+	}
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName, http.HandlerFunc(record)),
+		mock.WithRequestMatchHandler(mock.DeleteReposDependabotSecretsByOwnerByRepoBySecretName, http.HandlerFunc(record)),
+		mock.WithRequestMatch(mock.GetReposByOwnerByRepo, map[string]int64{"id": 42}),
+		mock.WithRequestMatchHandler(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets/{secret_name}",
+			Method:  "DELETE",
+		}, http.HandlerFunc(record)),
+	)
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repository: "test_repo",
+			Prune:      true,
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+			}},
+		},
+	}
+	repo := &secretv1alpha1.RepositoryStatus{Name: "test_repo", Secrets: []secretv1alpha1.SecretStatus{
+		{Kind: "Actions", Name: "TOKEN", Version: "1"},
+		{Kind: "Actions", Name: "REMOVED", Version: "1"},
+		{Kind: "DependaBot", Name: "FAILING", Version: "1"},
+		{Kind: environmentKind("production"), Name: "DEPLOY_KEY", Version: "1"},
+	}}
+	r := &GithubSecretReconciler{
+		Github: github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Config: config.Config{Owner: "fr123k"},
+	}
+
+	desired := desiredSecrets(instance.Spec)
+	r.pruneSecrets(logr.Discard(), instance, repo, desired)
+
+	assert.Equal(t, []string{
+		"DELETE /repos/fr123k/test_repo/actions/secrets/REMOVED",
+		"DELETE /repos/fr123k/test_repo/dependabot/secrets/FAILING",
+		"DELETE /repositories/42/environments/production/secrets/DEPLOY_KEY",
+	}, removed)
+	// the secret that couldn't be removed stays in the status to retry
+	assert.Equal(t, []secretv1alpha1.SecretStatus{
+		{Kind: "Actions", Name: "TOKEN", Version: "1"},
+		{Kind: "DependaBot", Name: "FAILING", Version: "1"},
+	}, retainSecretStatus(repo.Secrets, desired))
+	assert.Equal(t, secretv1alpha1.ConditionTypeGithubActionSecretError, repo.Conditions[0].Type)
+}
+
+func TestReconcileDeleteKeepsUnrecordedSecrets(t *testing.T) {
+	var removed []string
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				removed = append(removed, r.URL.Path)
+			}),
+		),
+	)
+	instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyDelete)
+	// only TOKEN was pushed by the operator. REMOVED was refused because of the Fail conflict policy
+	// and the source of WEBHOOK never could be read, they were created manually in Github
+	instance.Spec.ActionsSecrets.Secrets[1].ConflictPolicy = secretv1alpha1.ConflictPolicyFail
+	instance.Status.Repositories = []secretv1alpha1.RepositoryStatus{
+		{Name: "test_repo", Secrets: []secretv1alpha1.SecretStatus{{Kind: "Actions", Name: "TOKEN", Version: "1"}}},
+	}
+	r := newDeletionReconciler(t, instance, githubClient)
+
+	_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/repos/fr123k/test_repo/actions/secrets/TOKEN",
+	}, removed)
+}
+
+func TestReconcileDeleteKeepsAdoptedSecrets(t *testing.T) {
+	for _, pruneAdopted := range []bool{false, true} {
+		var removed []string
+		githubClient := mock.NewMockedHTTPClient(
+			mock.WithRequestMatchHandler(
+				mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					removed = append(removed, r.URL.Path)
+				}),
+			),
+		)
+		instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyDelete)
+		instance.Spec.PruneAdopted = pruneAdopted
+		instance.Status.Repositories = []secretv1alpha1.RepositoryStatus{
+			{Name: "test_repo", Secrets: []secretv1alpha1.SecretStatus{
+				{Kind: "Actions", Name: "TOKEN", Version: "1", Adopted: true},
+				{Kind: "Actions", Name: "REMOVED", Version: "1"},
+			}},
+		}
+		r := newDeletionReconciler(t, instance, githubClient)
+
+		_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+		assert.NoError(t, err)
+		expected := []string{
+			"/repos/fr123k/test_repo/actions/secrets/REMOVED",
+		}
+		if pruneAdopted {
+			expected = append([]string{"/repos/fr123k/test_repo/actions/secrets/TOKEN"}, expected...)
+		}
+		assert.Equal(t, expected, removed)
+	}
+}
+
+func TestPruneSecretsKeepsAdopted(t *testing.T) {
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       secretv1alpha1.GithubSecretSpec{Repository: "test_repo", Prune: true},
+	}
+	repo := &secretv1alpha1.RepositoryStatus{Name: "test_repo", Secrets: []secretv1alpha1.SecretStatus{
+		{Kind: "Actions", Name: "MANUAL", Version: "1", Adopted: true},
+	}}
+	var removed []string
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				removed = append(removed, r.URL.Path)
+			}),
+		),
+	)
+	r := &GithubSecretReconciler{
+		Github: github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Config: config.Config{Owner: "fr123k"},
+	}
+
+	desired := desiredSecrets(instance.Spec)
+	r.pruneSecrets(logr.Discard(), instance, repo, desired)
+
+	// the adopted secret is forgotten but stays in Github
+	assert.Empty(t, removed)
+	assert.Empty(t, retainSecretStatus(repo.Secrets, desired))
+	assert.Empty(t, repo.Conditions)
+}
+
+func TestReconcileDeleteRemovesWrittenVariables(t *testing.T) {
+	var removed []string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		removed = append(removed, r.URL.Path)
+	}
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName, http.HandlerFunc(record)),
+		mock.WithRequestMatchHandler(mock.DeleteReposActionsVariablesByOwnerByRepoByName, http.HandlerFunc(record)),
+		mock.WithRequestMatch(mock.GetReposByOwnerByRepo, map[string]int64{"id": 42}),
+		mock.WithRequestMatchHandler(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/variables/{name}",
+			Method:  "DELETE",
+		}, http.HandlerFunc(record)),
+	)
+	instance := deletedGithubSecret(secretv1alpha1.DeletionPolicyDelete)
+	instance.Spec.ActionsSecrets.Secrets = nil
+	instance.Spec.Variables = []secretv1alpha1.Variable{{Name: "REGION", Value: "europe-west1"}, {Name: "MANUAL", Value: "value"}}
+	instance.Spec.Environments = map[string]secretv1alpha1.Environment{
+		"production": {Variables: []secretv1alpha1.Variable{{Name: "CLUSTER", Value: "prod"}, {Name: "MANUAL", Value: "value"}}},
+	}
+	// MANUAL already had the desired value, the operator never wrote it
+	instance.Status.Repositories = []secretv1alpha1.RepositoryStatus{
+		{Name: "test_repo", Variables: []secretv1alpha1.VariableStatus{
+			{Kind: "Repository", Name: "REGION"},
+			{Kind: environmentKind("production"), Name: "CLUSTER"},
+		}},
+	}
+	r := newDeletionReconciler(t, instance, githubClient)
+
+	_, err := r.reconcileDelete(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/repos/fr123k/test_repo/actions/variables/REGION",
+		"/repositories/42/environments/production/variables/CLUSTER",
+	}, removed)
+}
+
+func TestReconcileVariablesRecordsWrittenVariables(t *testing.T) {
+	r := &GithubSecretReconciler{}
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec:       secretv1alpha1.GithubSecretSpec{Repository: "test_repo"},
+	}
+	noop := func(owner, repository, name, value string) error { return nil }
+	target := variableTarget{
+		kind: "Repository",
+		variables: []secretv1alpha1.Variable{
+			{Name: "CREATED", Value: "value"},
+			{Name: "UPDATED", Value: "value"},
+			{Name: "MANUAL", Value: "value"},
+		},
+		list: func(owner, repository string) (*github.Variables, error) {
+			return &github.Variables{Variables: []*github.Variable{{Name: "UPDATED", Value: "old"}, {Name: "MANUAL", Value: "value"}}}, nil
+		},
+		add:    noop,
+		update: noop,
+	}
+
+	repo := &secretv1alpha1.RepositoryStatus{Name: "test_repo"}
+	_, err := r.reconcileVariables(context.Background(), logr.Discard(), instance, repo, target)
+	assert.NoError(t, err)
+	assert.Equal(t, []secretv1alpha1.VariableStatus{
+		{Kind: "Repository", Name: "CREATED"},
+		{Kind: "Repository", Name: "UPDATED"},
+	}, repo.Variables)
+}
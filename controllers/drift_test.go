@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+// driftedGithubSecret returns a GithubSecret whose secrets were all pushed at the given time.
+func driftedGithubSecret(pushed time.Time) *secretv1alpha1.GithubSecret {
+	lastUpdated := metav1.NewTime(pushed)
+	return &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repository: "test_repo",
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "DELETED", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+				{Name: "MODIFIED", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+				{Name: "UNCHANGED", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+			}},
+		},
+		Status: secretv1alpha1.GithubSecretStatus{Repositories: []secretv1alpha1.RepositoryStatus{
+			{Name: "test_repo", Secrets: []secretv1alpha1.SecretStatus{
+				{Kind: "Actions", Name: "DELETED", Version: "1", LastUpdated: &lastUpdated},
+				{Kind: "Actions", Name: "MODIFIED", Version: "1", LastUpdated: &lastUpdated},
+				{Kind: "Actions", Name: "UNCHANGED", Version: "1", LastUpdated: &lastUpdated},
+			}},
+		}},
+	}
+}
+
+func newDriftReconciler(pushed time.Time, put http.HandlerFunc) *GithubSecretReconciler {
+	key, keyID := "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=", "test_key_id"
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsSecretsByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// DELETED was removed and MODIFIED was changed in the UI after the operator pushed them
+				_, _ = w.Write(mock.MustMarshal(gogithub.Secrets{TotalCount: 2, Secrets: []*gogithub.Secret{
+					{Name: "MODIFIED", UpdatedAt: gogithub.Timestamp{Time: pushed.Add(time.Hour)}},
+					{Name: "UNCHANGED", UpdatedAt: gogithub.Timestamp{Time: pushed}},
+				}}))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsSecretsPublicKeyByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(gogithub.PublicKey{Key: &key, KeyID: &keyID}))
+			}),
+		),
+		mock.WithRequestMatchHandler(mock.PutReposActionsSecretsByOwnerByRepoBySecretName, put),
+	)
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"token": "value"})
+	return &GithubSecretReconciler{
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: sources,
+		Config:  config.Config{Owner: "fr123k"},
+	}
+}
+
+func TestReconcileRestoresSecretsChangedInGithub(t *testing.T) {
+	pushed := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	var calls []string
+	r := newDriftReconciler(pushed, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	})
+	instance := driftedGithubSecret(pushed)
+
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"PUT /repos/fr123k/test_repo/actions/secrets/DELETED",
+		"PUT /repos/fr123k/test_repo/actions/secrets/MODIFIED",
+	}, calls)
+	repo := findRepositoryStatus(instance.Status.Repositories, "test_repo")
+	assert.True(t, apimeta.IsStatusConditionTrue(repo.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.True(t, findSecretStatus(repo.Secrets, "Actions", "MODIFIED").LastUpdated.After(pushed))
+}
+
+func TestReconcileReportsFailedRestore(t *testing.T) {
+	pushed := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	r := newDriftReconciler(pushed, func(w http.ResponseWriter, r *http.Request) {
+		mock.WriteError(w, http.StatusInternalServerError, "github went belly up or something")
+	})
+	instance := driftedGithubSecret(pushed)
+
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	repo := findRepositoryStatus(instance.Status.Repositories, "test_repo")
+	assert.False(t, apimeta.IsStatusConditionTrue(repo.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.NotNil(t, apimeta.FindStatusCondition(instance.Status.Conditions, secretv1alpha1.ConditionTypeGithubActionSecretError))
+	// the secrets are retried on the next pass
+	assert.Equal(t, pushed, findSecretStatus(repo.Secrets, "Actions", "MODIFIED").LastUpdated.Time)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+func TestReconcileEnvironmentCreatesMissingEnvironment(t *testing.T) {
+	var calls []string
+	key, keyID := "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=", "test_key_id"
+	record := func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	}
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposEnvironmentsByOwnerByRepoByEnvironmentName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mock.WriteError(w, http.StatusNotFound, "Not Found")
+			}),
+		),
+		mock.WithRequestMatchHandler(mock.PutReposEnvironmentsByOwnerByRepoByEnvironmentName, http.HandlerFunc(record)),
+		mock.WithRequestMatch(mock.GetReposByOwnerByRepo, map[string]int64{"id": 42}),
+		mock.WithRequestMatch(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets",
+			Method:  "GET",
+		}, gogithub.Secrets{}),
+		mock.WithRequestMatch(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets/public-key",
+			Method:  "GET",
+		}, gogithub.PublicKey{Key: &key, KeyID: &keyID}),
+		mock.WithRequestMatchHandler(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets/{secret_name}",
+			Method:  "PUT",
+		}, http.HandlerFunc(record)),
+		mock.WithRequestMatch(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/variables",
+			Method:  "GET",
+		}, gogithub.ActionsVariables{}),
+		mock.WithRequestMatchHandler(mock.EndpointPattern{
+			Pattern: "/repositories/{repository_id}/environments/{environment_name}/variables",
+			Method:  "POST",
+		}, http.HandlerFunc(record)),
+	)
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"deploy-key": "value"})
+	r := &GithubSecretReconciler{
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: sources,
+		Config:  config.Config{Owner: "fr123k"},
+	}
+
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repository: "test_repo",
+			Environments: map[string]secretv1alpha1.Environment{
+				"production": {
+					Secrets:   []secretv1alpha1.Secrets{{Name: "DEPLOY_KEY", SecretRef: secretv1alpha1.SecretRef{Key: "deploy-key"}}},
+					Variables: []secretv1alpha1.Variable{{Name: "REGION", Value: "europe-west1"}},
+				},
+			},
+		},
+	}
+
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	// the environment is created before its secrets and variables are pushed
+	assert.Equal(t, []string{
+		"PUT /repos/fr123k/test_repo/environments/production",
+		"PUT /repositories/42/environments/production/secrets/DEPLOY_KEY",
+		"POST /repositories/42/environments/production/variables",
+	}, calls)
+	repo := findRepositoryStatus(instance.Status.Repositories, "test_repo")
+	assert.True(t, apimeta.IsStatusConditionTrue(repo.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.NotNil(t, findSecretStatus(repo.Secrets, environmentKind("production"), "DEPLOY_KEY"))
+}
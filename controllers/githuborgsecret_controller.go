@@ -0,0 +1,422 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+	"github.com/go-logr/logr"
+)
+
+// GithubOrgSecretReconciler reconciles a GithubOrgSecret object
+type GithubOrgSecretReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	Github github.GithubClient
+	// Sources resolve the secret references of the spec.
+	Sources *source.Registry
+
+	Config config.Config
+}
+
+//+kubebuilder:rbac:groups=secret.fr123k.uk,resources=githuborgsecrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=secret.fr123k.uk,resources=githuborgsecrets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=secret.fr123k.uk,resources=githuborgsecrets/finalizers,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// Reconcile stores the secrets of a GithubOrgSecret as Github organization secrets
+// and keeps their visibility and selected repositories in sync with the spec.
+func (r *GithubOrgSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	reqLogger := log.WithValues("Request.Name", req.Name)
+
+	reqLogger.Info("Reconciling GithubOrgSecret")
+
+	instance := &secretv1alpha1.GithubOrgSecret{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, "Reconcile", "Secret", instance)
+		return reconcile.Result{}, err
+	}
+
+	previous := resetErrorConditions(&instance.Status.Conditions)
+
+	// the Kubernetes Secrets of generated values are owned by the custom resource
+	ctx = source.WithOwner(ctx, instance)
+	ctx = source.WithCache(ctx)
+	result, err := r.reconcileOrganization(ctx, reqLogger, instance)
+
+	reqLogger.Info("Reconcile GithubOrgSecret", "GithubOrgSecrets", instance.Spec)
+
+	setReadyCondition(&instance.Status.Conditions, previous, fmt.Sprintf("Secret %s", instance.Name), instance.GetGeneration())
+
+	updateErr := r.Status().Update(ctx, instance)
+	if updateErr != nil {
+		log.Error(updateErr, "Failed to update GithubOrgSecrets status")
+		return reconcile.Result{}, updateErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.Config.ResyncPeriod}, nil
+}
+
+// reconcileOrganization compares the secrets and variables of the spec with the ones in the
+// organization and creates or updates them.
+func (r *GithubOrgSecretReconciler) reconcileOrganization(ctx context.Context, reqLogger logr.Logger, instance *secretv1alpha1.GithubOrgSecret) (ctrl.Result, error) {
+	org := instance.Spec.Organization
+	if org == "" {
+		org = r.Config.Owner
+	}
+
+	instance.Status.Secrets = retainSecretStatus(instance.Status.Secrets, desiredOrgSecrets(instance.Spec))
+
+	targets := []orgSecretTarget{
+		{
+			kind:    "DependaBot",
+			secrets: instance.Spec.DependaBotSecrets,
+			list:    r.Github.ListOrgDependaBotSecrets,
+			add: func(org, name, value, visibility string, selectedRepositoryIDs []int64) error {
+				_, err := r.Github.AddOrgDependaBotSecrets(org, name, value, visibility, selectedRepositoryIDs)
+				return err
+			},
+			listRepositories: r.Github.ListOrgDependaBotSecretRepositories,
+			setRepositories:  r.Github.SetOrgDependaBotSecretRepositories,
+		},
+		{
+			kind:    "Actions",
+			secrets: instance.Spec.ActionsSecrets,
+			list:    r.Github.ListOrgActionsSecrets,
+			add: func(org, name, value, visibility string, selectedRepositoryIDs []int64) error {
+				_, err := r.Github.AddOrgActionsSecrets(org, name, value, visibility, selectedRepositoryIDs)
+				return err
+			},
+			listRepositories: r.Github.ListOrgActionsSecretRepositories,
+			setRepositories:  r.Github.SetOrgActionsSecretRepositories,
+		},
+		{
+			kind:    "Codespaces",
+			secrets: instance.Spec.CodespacesSecrets,
+			list:    r.Github.ListOrgCodespacesSecrets,
+			add: func(org, name, value, visibility string, selectedRepositoryIDs []int64) error {
+				_, err := r.Github.AddOrgCodespacesSecrets(org, name, value, visibility, selectedRepositoryIDs)
+				return err
+			},
+			listRepositories: r.Github.ListOrgCodespacesSecretRepositories,
+			setRepositories:  r.Github.SetOrgCodespacesSecretRepositories,
+		},
+	}
+
+	for _, target := range targets {
+		if len(target.secrets.Secrets) == 0 {
+			continue
+		}
+		result, err := r.reconcileOrgSecrets(ctx, reqLogger, instance, org, target)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if len(instance.Spec.Variables.Variables) > 0 {
+		result, err := r.reconcileOrgVariables(ctx, reqLogger, instance, org, instance.Spec.Variables)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// orgSecretTarget bundles the Github API calls for one kind of organization secret
+// (DependaBot, Actions, Codespaces) so they can share the same reconcile logic.
+type orgSecretTarget struct {
+	kind             string
+	secrets          secretv1alpha1.OrgSecrets
+	list             func(org string) (*github.Secrets, error)
+	add              func(org, name, value, visibility string, selectedRepositoryIDs []int64) error
+	listRepositories func(org, name string) ([]int64, error)
+	setRepositories  func(org, name string, selectedRepositoryIDs []int64) error
+}
+
+// reconcileOrgSecrets creates the secrets of the target that don't exist yet in the organization,
+// pushes the ones again whose source version or visibility changed and updates the selected
+// repositories of the existing ones.
+func (r *GithubOrgSecretReconciler) reconcileOrgSecrets(ctx context.Context, reqLogger logr.Logger, instance *secretv1alpha1.GithubOrgSecret, org string, target orgSecretTarget) (ctrl.Result, error) {
+	visibility := target.secrets.Visibility
+	if visibility == "" {
+		visibility = secretv1alpha1.OrgSecretVisibilityPrivate
+	}
+
+	selectedRepositoryIDs, err := r.selectedRepositoryIDs(reqLogger, instance, org, visibility, target.secrets.SelectedRepositories)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	secrets, err := target.list(org)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list %s organization secrets. Error:%s", target.kind, err.Error())
+		reqLogger.Error(err, msg, "Secret", instance)
+		apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		return reconcile.Result{}, err
+	}
+
+	existing := map[string]*github.Secret{}
+	for _, secret := range secrets.Secrets {
+		existing[secret.Name] = secret
+	}
+
+	for _, secret := range target.secrets.Secrets {
+		value, conditionType, err := secretValue(ctx, r.Sources, "", secret)
+		if err != nil {
+			msg := fmt.Sprintf("Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, conditionType, instance.GetGeneration()))
+			return reconcile.Result{Requeue: true, RequeueAfter: 5 * time.Minute}, err
+		}
+
+		current, ok := existing[secret.Name]
+		status := findSecretStatus(instance.Status.Secrets, target.kind, secret.Name)
+		adopted := status != nil && status.Adopted
+		if ok && status == nil {
+			// the secret exists in the organization but wasn't pushed by the operator, its value is
+			// unknown so it's pushed once unless the conflict policy refuses it
+			policy := conflictPolicy(instance.Spec.ConflictPolicy, secret)
+			if policy == secretv1alpha1.ConflictPolicyFail {
+				setConflictCondition(&instance.Status.Conditions, target.kind, secret.Name, instance.GetGeneration())
+				reqLogger.Info("organization secret already exists in Github", "secret", secret.Name, "kind", target.kind, "organization", org)
+				continue
+			}
+			adopted = policy == secretv1alpha1.ConflictPolicyAdopt
+		}
+		if ok && status != nil && current.Visibility == visibility {
+			if status.Version == value.Version && !modifiedInGithub(current, status) {
+				if visibility == secretv1alpha1.OrgSecretVisibilitySelected {
+					err = syncSelectedRepositories(org, secret.Name, selectedRepositoryIDs, target.listRepositories, target.setRepositories)
+					if err != nil {
+						msg := fmt.Sprintf("Error:%s", err.Error())
+						reqLogger.Error(err, msg)
+						apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+					}
+				}
+				continue
+			}
+		}
+
+		err = target.add(org, secret.Name, value.Value, visibility, selectedRepositoryIDs)
+		if err != nil {
+			msg := fmt.Sprintf("Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		} else {
+			now := metav1.Now()
+			setSecretStatus(&instance.Status.Secrets, secretv1alpha1.SecretStatus{Name: secret.Name, Kind: target.kind, Version: value.Version, LastUpdated: &now, Adopted: adopted})
+			reqLogger.Info("pushed organization secret", "secret", secret.Name, "kind", target.kind, "version", value.Version, "organization", org, "visibility", visibility)
+		}
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// reconcileOrgVariables creates the missing organization variables and updates the ones whose
+// value or visibility differs from the spec.
+func (r *GithubOrgSecretReconciler) reconcileOrgVariables(ctx context.Context, reqLogger logr.Logger, instance *secretv1alpha1.GithubOrgSecret, org string, spec secretv1alpha1.OrgVariables) (ctrl.Result, error) {
+	visibility := spec.Visibility
+	if visibility == "" {
+		visibility = secretv1alpha1.OrgSecretVisibilityPrivate
+	}
+
+	selectedRepositoryIDs, err := r.selectedRepositoryIDs(reqLogger, instance, org, visibility, spec.SelectedRepositories)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	variables, err := r.Github.ListOrgVariables(org)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list organization variables. Error:%s", err.Error())
+		reqLogger.Error(err, msg, "Secret", instance)
+		apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		return reconcile.Result{}, err
+	}
+
+	existing := map[string]*github.Variable{}
+	for _, variable := range variables.Variables {
+		existing[variable.Name] = variable
+	}
+
+	for _, variable := range spec.Variables {
+		desired, err := variableValue(ctx, r.Sources, "", variable)
+		if err != nil {
+			msg := fmt.Sprintf("Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, errorConditionType(r.Sources, *variable.ValueFrom, err), instance.GetGeneration()))
+			return reconcile.Result{Requeue: true, RequeueAfter: 5 * time.Minute}, err
+		}
+
+		current, ok := existing[variable.Name]
+		switch {
+		case !ok:
+			err = r.Github.AddOrgVariable(org, variable.Name, desired, visibility, selectedRepositoryIDs)
+		case current.Value != desired || current.GetVisibility() != visibility:
+			err = r.Github.UpdateOrgVariable(org, variable.Name, desired, visibility, selectedRepositoryIDs)
+		case visibility == secretv1alpha1.OrgSecretVisibilitySelected:
+			err = syncSelectedRepositories(org, variable.Name, selectedRepositoryIDs, r.Github.ListOrgVariableRepositories, r.Github.SetOrgVariableRepositories)
+		default:
+			continue
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		} else {
+			reqLogger.Info("set organization variable", "variable", variable.Name, "organization", org, "visibility", visibility)
+		}
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// desiredOrgSecrets returns the kind and name of every secret of the spec in the format used
+// by the SecretStatus entries.
+func desiredOrgSecrets(spec secretv1alpha1.GithubOrgSecretSpec) map[string]bool {
+	desired := map[string]bool{}
+	add := func(kind string, secrets secretv1alpha1.OrgSecrets) {
+		for _, secret := range secrets.Secrets {
+			desired[secretStatusKey(kind, secret.Name)] = true
+		}
+	}
+	add("DependaBot", spec.DependaBotSecrets)
+	add("Actions", spec.ActionsSecrets)
+	add("Codespaces", spec.CodespacesSecrets)
+	return desired
+}
+
+// syncSelectedRepositories sets the selected repositories of an organization secret or variable
+// unless they're selected already, so a resync doesn't write to Github.
+func syncSelectedRepositories(org, name string, selectedRepositoryIDs []int64, list func(org, name string) ([]int64, error), set func(org, name string, selectedRepositoryIDs []int64) error) error {
+	current, err := list(org, name)
+	if err != nil {
+		return err
+	}
+	current = slices.Clone(current)
+	desired := slices.Clone(selectedRepositoryIDs)
+	slices.Sort(current)
+	slices.Sort(desired)
+	if slices.Equal(current, desired) {
+		return nil
+	}
+	return set(org, name, selectedRepositoryIDs)
+}
+
+// selectedRepositoryIDs resolves the ids of the selected repositories, they are only needed
+// if the visibility is set to selected.
+func (r *GithubOrgSecretReconciler) selectedRepositoryIDs(reqLogger logr.Logger, instance *secretv1alpha1.GithubOrgSecret, org string, visibility string, repositories []string) ([]int64, error) {
+	if visibility != secretv1alpha1.OrgSecretVisibilitySelected {
+		return nil, nil
+	}
+
+	var ids []int64
+	for _, repository := range repositories {
+		id, err := r.Github.RepositoryID(org, repository)
+		if err != nil {
+			msg := fmt.Sprintf("failed to resolve repository %s. Error:%s", repository, err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GithubOrgSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &secretv1alpha1.GithubOrgSecret{}, secretRefIndex, func(obj client.Object) []string {
+		instance := obj.(*secretv1alpha1.GithubOrgSecret)
+		return kubernetesSecretRefs("", orgSpecSecretRefs(instance.Spec))
+	})
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &secretv1alpha1.GithubOrgSecret{}, configMapRefIndex, func(obj client.Object) []string {
+		instance := obj.(*secretv1alpha1.GithubOrgSecret)
+		return configMapRefs("", orgSpecSecretRefs(instance.Spec))
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&secretv1alpha1.GithubOrgSecret{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsFor(secretRefIndex))).
+		Watches(&v1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.requestsFor(configMapRefIndex))).
+		Complete(r)
+}
+
+// requestsFor returns a function that maps a Kubernetes Secret or ConfigMap to the GithubOrgSecrets
+// referencing it according to the index.
+func (r *GithubOrgSecretReconciler) requestsFor(index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		instances := &secretv1alpha1.GithubOrgSecretList{}
+		err := r.List(ctx, instances, client.MatchingFields{index: fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())})
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to list GithubOrgSecrets referencing object", "object", obj.GetName())
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(instances.Items))
+		for _, instance := range instances.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&instance)})
+		}
+		return requests
+	}
+}
+
+// orgSpecSecretRefs returns the secret references of all secrets and variables of the spec.
+func orgSpecSecretRefs(spec secretv1alpha1.GithubOrgSecretSpec) []secretv1alpha1.SecretRef {
+	refs := secretRefs(spec.DependaBotSecrets.Secrets)
+	refs = append(refs, secretRefs(spec.ActionsSecrets.Secrets)...)
+	refs = append(refs, secretRefs(spec.CodespacesSecrets.Secrets)...)
+	return append(refs, variableRefs(spec.Variables.Variables)...)
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+var _ = Describe("GithubOrgSecret controller", func() {
+
+	const (
+		OrgSecretName = "test-org-secret"
+		timeout       = time.Second * 30
+		interval      = time.Millisecond * 250
+	)
+
+	Context("When creating a GithubOrgSecret", func() {
+		It("Should store the organization secrets and become ready", func() {
+			ctx := context.Background()
+			secret := &secretv1alpha1.GithubOrgSecret{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "secret.fr123k.uk/v1alpha1",
+					Kind:       "GithubOrgSecret",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: OrgSecretName,
+				},
+				Spec: secretv1alpha1.GithubOrgSecretSpec{
+					Organization: "org",
+					ActionsSecrets: secretv1alpha1.OrgSecrets{
+						Visibility: secretv1alpha1.OrgSecretVisibilityAll,
+						Secrets: []secretv1alpha1.Secrets{
+							{Name: "name", SecretRef: secretv1alpha1.SecretRef{Key: "key", Source: "GCP"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			lookupKey := types.NamespacedName{Name: OrgSecretName}
+			created := &secretv1alpha1.GithubOrgSecret{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, lookupKey, created)
+				if err != nil {
+					return false
+				}
+				return len(created.Status.Conditions) > 0
+			}, timeout, interval).Should(BeTrue())
+			Expect(created.Status.Conditions[0].Reason).Should(Equal("Ready"))
+			Expect(string(created.Status.Conditions[0].Status)).Should(Equal("True"))
+		})
+	})
+})
@@ -19,14 +19,19 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "k8s.io/api/core/v1"
@@ -35,14 +40,13 @@ import (
 
 	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
 	"github.com/fr123k/github-operator/pkg/config"
-	"github.com/fr123k/github-operator/pkg/gcloud"
 	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
 	"github.com/go-logr/logr"
 )
 
-var GithubSecretOperatorNamespace string
-
 const (
+	// Finalizer keeps the GithubSecrets until their deletion policy has been applied.
 	Finalizer = "secret.fr123k.uk/finalizer"
 )
 
@@ -52,7 +56,8 @@ type GithubSecretReconciler struct {
 	Scheme *runtime.Scheme
 
 	Github github.GithubClient
-	GCloud gcloud.GCloudClient
+	// Sources resolve the secret references of the spec.
+	Sources *source.Registry
 
 	Config config.Config
 }
@@ -60,6 +65,8 @@ type GithubSecretReconciler struct {
 //+kubebuilder:rbac:groups=secret.fr123k.uk,resources=githubsecrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=secret.fr123k.uk,resources=githubsecrets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=secret.fr123k.uk,resources=githubsecrets/finalizers,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -67,14 +74,27 @@ type GithubSecretReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.1/pkg/reconcile
 func (r *GithubSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return r.reconcileObject(ctx, req, "GithubSecret", &secretv1alpha1.GithubSecret{})
+}
+
+// githubSecretObject is implemented by the GithubSecret and the ClusterGithubSecret, which share
+// the spec and status and are reconciled by the same logic.
+type githubSecretObject interface {
+	client.Object
+	GetSpec() *secretv1alpha1.GithubSecretSpec
+	GetStatus() *secretv1alpha1.GithubSecretStatus
+}
+
+// reconcileObject fetches the requested GithubSecret or ClusterGithubSecret into the instance
+// and reconciles it.
+func (r *GithubSecretReconciler) reconcileObject(ctx context.Context, req ctrl.Request, kind string, instance githubSecretObject) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
 	reqLogger := log.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
 
-	reqLogger.Info("Reconciling GithubSecret")
+	reqLogger.Info("Reconciling " + kind)
 
 	// Fetch the Github Secret Operator Secret instance
-	instance := &secretv1alpha1.GithubSecret{}
 	err := r.Get(context.TODO(), req.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -87,113 +107,495 @@ func (r *GithubSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		reqLogger.Error(err, "Reconcile", "Secret", instance)
 		return reconcile.Result{}, err
 	}
+	migrateSecretStatus(instance.GetStatus(), instance.GetSpec().Repository)
 
-	if instance.Status.Conditions != nil {
-		for _, v := range instance.Status.Conditions {
-			if v.Type == secretv1alpha1.ConditionTypeReady {
-				return reconcile.Result{}, nil
-			}
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return r.reconcileDelete(ctx, reqLogger, instance)
+	}
+	if !controllerutil.ContainsFinalizer(instance, Finalizer) {
+		controllerutil.AddFinalizer(instance, Finalizer)
+		err = r.Update(ctx, instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed to add finalizer")
+			return reconcile.Result{}, err
+		}
+	}
+
+	previous := resetErrorConditions(&instance.GetStatus().Conditions)
+
+	// the Kubernetes Secrets of generated values are owned by the custom resource
+	ctx = source.WithOwner(ctx, instance)
+	// the secrets are read once per pass, not once per repository
+	ctx = source.WithCache(ctx)
+	result, err := r.reconcileRepositories(ctx, reqLogger, instance)
+
+	reqLogger.Info("Reconcile "+kind, "GithubSecrets", instance.GetSpec())
+
+	setReadyCondition(&instance.GetStatus().Conditions, previous, fmt.Sprintf("Secret %s", instance.GetName()), instance.GetGeneration())
+
+	updateErr := r.Status().Update(ctx, instance)
+	if updateErr != nil {
+		log.Error(updateErr, "Failed to update status", "kind", kind)
+		return reconcile.Result{}, updateErr
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// requeue to detect and revert changes made to the secrets in Github
+	return ctrl.Result{RequeueAfter: r.Config.ResyncPeriod}, nil
+}
+
+// reconcileRepository compares the secrets and variables of the spec with the ones in the
+// repository and creates the missing ones. The failures are reported in the status of the repository.
+func (r *GithubSecretReconciler) reconcileRepository(ctx context.Context, reqLogger logr.Logger, instance githubSecretObject, repo *secretv1alpha1.RepositoryStatus) (ctrl.Result, error) {
+	desired := desiredSecrets(*instance.GetSpec())
+	if instance.GetSpec().Prune {
+		r.pruneSecrets(reqLogger, instance, repo, desired)
+	}
+	repo.Secrets = retainSecretStatus(repo.Secrets, desired)
+	repo.Variables = retainVariableStatus(repo.Variables, desiredVariables(*instance.GetSpec()))
+
+	targets := []secretTarget{
+		{
+			kind:    "DependaBot",
+			secrets: instance.GetSpec().DependaBotSecrets.Secrets,
+			list:    r.Github.ListDependaBotSecrets,
+			add: func(owner, repository, name, value string) error {
+				_, err := r.Github.AddDependaBotSecrets(owner, repository, name, value)
+				return err
+			},
+		},
+		{
+			kind:    "Actions",
+			secrets: instance.GetSpec().ActionsSecrets.Secrets,
+			list:    r.Github.ListActionsSecrets,
+			add: func(owner, repository, name, value string) error {
+				_, err := r.Github.AddActionsSecrets(owner, repository, name, value)
+				return err
+			},
+		},
+		{
+			kind:    "Codespaces",
+			secrets: instance.GetSpec().CodespacesSecrets.Secrets,
+			list:    r.Github.ListCodespacesSecrets,
+			add: func(owner, repository, name, value string) error {
+				_, err := r.Github.AddCodespacesSecrets(owner, repository, name, value)
+				return err
+			},
+		},
+	}
+
+	for _, target := range targets {
+		if len(target.secrets) == 0 {
+			continue
+		}
+		result, err := r.reconcileSecrets(ctx, reqLogger, instance, repo, target)
+		if err != nil {
+			return result, err
 		}
 	}
 
-	repository := instance.Spec.Repository
+	if len(instance.GetSpec().Variables) > 0 {
+		target := variableTarget{
+			kind:      "Repository",
+			variables: instance.GetSpec().Variables,
+			list:      r.Github.ListRepoVariables,
+			add:       r.Github.AddRepoVariable,
+			update:    r.Github.UpdateRepoVariable,
+		}
+		result, err := r.reconcileVariables(ctx, reqLogger, instance, repo, target)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	environments := make([]string, 0, len(instance.GetSpec().Environments))
+	for environment := range instance.GetSpec().Environments {
+		environments = append(environments, environment)
+	}
+	sort.Strings(environments)
 
-	secretsConfig := map[string]secretv1alpha1.Secrets{}
-	for _, v := range instance.Spec.DependaBotSecrets.Secrets {
-		secretsConfig[v.Name] = v
+	for _, environment := range environments {
+		result, err := r.reconcileEnvironment(ctx, reqLogger, instance, repo, environment, instance.GetSpec().Environments[environment])
+		if err != nil {
+			return result, err
+		}
 	}
 
-	secrets, err := r.Github.ListDependaBotSecrets(repository)
+	return reconcile.Result{}, nil
+}
+
+// secretTarget bundles the Github API calls for one kind of repository secret
+// (DependaBot, Actions, Codespaces) so they can share the same reconcile logic.
+type secretTarget struct {
+	kind    string
+	secrets []secretv1alpha1.Secrets
+	list    func(owner, repository string) (*github.Secrets, error)
+	add     func(owner, repository, name, value string) error
+}
+
+// reconcileSecrets creates the secrets of the target that don't exist yet in the repository
+// and pushes the ones again whose source version changed since they were pushed last.
+func (r *GithubSecretReconciler) reconcileSecrets(ctx context.Context, reqLogger logr.Logger, instance githubSecretObject, repo *secretv1alpha1.RepositoryStatus, target secretTarget) (ctrl.Result, error) {
+	owner, repository := r.repositoryOwner(*instance.GetSpec(), repo.Name)
+	secrets, err := target.list(owner, repository)
 	if err != nil {
-		msg := fmt.Sprintf("failed to list DependaBot secrets. Error:%s", err.Error())
+		msg := fmt.Sprintf("failed to list %s secrets. Error:%s", target.kind, err.Error())
 		reqLogger.Error(err, msg, "Secret", instance)
-		apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
 		return reconcile.Result{}, err
 	}
 
+	existing := map[string]*github.Secret{}
 	for _, secret := range secrets.Secrets {
-		delete(secretsConfig, secret.Name)
+		existing[secret.Name] = secret
 	}
 
-	for _, secret := range secretsConfig {
-		value, err := r.GCloud.GetSecretValue(secret.Key)
+	for _, secret := range target.secrets {
+		value, conditionType, err := secretValue(ctx, r.Sources, instance.GetNamespace(), secret)
 		if err != nil {
 			msg := fmt.Sprintf("Error:%s", err.Error())
 			reqLogger.Error(err, msg)
-			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGCPSecretManagerError, instance.GetGeneration()))
-			err = r.Status().Update(ctx, instance)
-			if err != nil {
-				log.Error(err, "Failed to update GithubSecrets status")
-				return reconcile.Result{}, err
-			}
+			apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, conditionType, instance.GetGeneration()))
 			return reconcile.Result{Requeue: true, RequeueAfter: 5 * time.Minute}, err
 		}
 
-		secret, err := r.Github.AddDependaBotSecrets(r.Config.Owner, repository, secret.Name, *value)
+		status := findSecretStatus(repo.Secrets, target.kind, secret.Name)
+		adopted := status != nil && status.Adopted
+		switch {
+		case existing[secret.Name] == nil:
+		case status == nil:
+			// the secret exists in Github but wasn't pushed by the operator, its value is unknown
+			// so it's pushed once unless the conflict policy refuses it
+			policy := conflictPolicy(instance.GetSpec().ConflictPolicy, secret)
+			if policy == secretv1alpha1.ConflictPolicyFail {
+				setConflictCondition(&repo.Conditions, target.kind, secret.Name, instance.GetGeneration())
+				reqLogger.Info("secret already exists in Github", "secret", secret.Name, "kind", target.kind, "repository", repository)
+				continue
+			}
+			adopted = policy == secretv1alpha1.ConflictPolicyAdopt
+		case status.Version == value.Version && !modifiedInGithub(existing[secret.Name], status):
+			continue
+		}
+
+		err = target.add(owner, repository, secret.Name, value.Value)
 		if err != nil {
 			msg := fmt.Sprintf("Error:%s", err.Error())
 			reqLogger.Error(err, msg)
-			apimeta.SetStatusCondition(&instance.Status.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+			apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
 		} else {
-			reqLogger.Info("added secret", "secret", secret.Name, "repository", repository)
+			now := metav1.Now()
+			setSecretStatus(&repo.Secrets, secretv1alpha1.SecretStatus{Name: secret.Name, Kind: target.kind, Version: value.Version, LastUpdated: &now, Adopted: adopted})
+			reqLogger.Info("pushed secret", "secret", secret.Name, "kind", target.kind, "version", value.Version, "repository", repository)
 		}
 	}
 
-	reqLogger.Info("Reconcile GithubSecret", "GithubSecrets", instance.Spec)
+	return reconcile.Result{}, nil
+}
+
+// reconcileEnvironment creates the deployment environment if it's missing, adds its missing secrets
+// and creates or updates its variables.
+func (r *GithubSecretReconciler) reconcileEnvironment(ctx context.Context, reqLogger logr.Logger, instance githubSecretObject, repo *secretv1alpha1.RepositoryStatus, environment string, spec secretv1alpha1.Environment) (ctrl.Result, error) {
+	owner, repository := r.repositoryOwner(*instance.GetSpec(), repo.Name)
+	err := r.Github.EnsureEnvironment(owner, repository, environment)
+	if err != nil {
+		msg := fmt.Sprintf("failed to create environment %s. Error:%s", environment, err.Error())
+		reqLogger.Error(err, msg, "Secret", instance)
+		apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		return reconcile.Result{}, err
+	}
 
-	apimeta.SetStatusCondition(&instance.Status.Conditions, Condition(metav1.ConditionTrue, fmt.Sprintf("Secret %s in ready state", instance.Name), secretv1alpha1.ConditionTypeReady, instance.GetGeneration()))
+	if len(spec.Secrets) > 0 {
+		target := secretTarget{
+			kind:    environmentKind(environment),
+			secrets: spec.Secrets,
+			list: func(owner, repository string) (*github.Secrets, error) {
+				return r.Github.ListEnvironmentSecrets(owner, repository, environment)
+			},
+			add: func(owner, repository, name, value string) error {
+				_, err := r.Github.AddEnvironmentSecrets(owner, repository, environment, name, value)
+				return err
+			},
+		}
+		result, err := r.reconcileSecrets(ctx, reqLogger, instance, repo, target)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if len(spec.Variables) > 0 {
+		target := variableTarget{
+			kind:      environmentKind(environment),
+			variables: spec.Variables,
+			list: func(owner, repository string) (*github.Variables, error) {
+				return r.Github.ListEnvironmentVariables(owner, repository, environment)
+			},
+			add: func(owner, repository, name, value string) error {
+				return r.Github.AddEnvironmentVariable(owner, repository, environment, name, value)
+			},
+			update: func(owner, repository, name, value string) error {
+				return r.Github.UpdateEnvironmentVariable(owner, repository, environment, name, value)
+			},
+		}
+		result, err := r.reconcileVariables(ctx, reqLogger, instance, repo, target)
+		if err != nil {
+			return result, err
+		}
+	}
 
-	err = r.Status().Update(ctx, instance)
+	return reconcile.Result{}, nil
+}
+
+// variableTarget bundles the Github API calls for the variables of a repository
+// or one of its environments so they can share the same reconcile logic.
+type variableTarget struct {
+	kind      string
+	variables []secretv1alpha1.Variable
+	list      func(owner, repository string) (*github.Variables, error)
+	add       func(owner, repository, name, value string) error
+	update    func(owner, repository, name, value string) error
+}
+
+// reconcileVariables creates the missing variables of the target and updates the ones
+// whose value differs from the desired one. Unlike secrets the values can be read back.
+func (r *GithubSecretReconciler) reconcileVariables(ctx context.Context, reqLogger logr.Logger, instance githubSecretObject, repo *secretv1alpha1.RepositoryStatus, target variableTarget) (ctrl.Result, error) {
+	owner, repository := r.repositoryOwner(*instance.GetSpec(), repo.Name)
+	variables, err := target.list(owner, repository)
 	if err != nil {
-		log.Error(err, "Failed to update GithubSecrets status")
+		msg := fmt.Sprintf("failed to list %s variables. Error:%s", target.kind, err.Error())
+		reqLogger.Error(err, msg, "Secret", instance)
+		apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
 		return reconcile.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	existing := map[string]string{}
+	for _, variable := range variables.Variables {
+		existing[variable.Name] = variable.Value
+	}
+
+	for _, variable := range target.variables {
+		desired, err := variableValue(ctx, r.Sources, instance.GetNamespace(), variable)
+		if err != nil {
+			msg := fmt.Sprintf("Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, errorConditionType(r.Sources, *variable.ValueFrom, err), instance.GetGeneration()))
+			return reconcile.Result{Requeue: true, RequeueAfter: 5 * time.Minute}, err
+		}
+
+		value, ok := existing[variable.Name]
+		switch {
+		case !ok:
+			err = target.add(owner, repository, variable.Name, desired)
+		case value != desired:
+			err = target.update(owner, repository, variable.Name, desired)
+		default:
+			continue
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&repo.Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+		} else {
+			setVariableStatus(&repo.Variables, secretv1alpha1.VariableStatus{Name: variable.Name, Kind: target.kind})
+			reqLogger.Info("set variable", "variable", variable.Name, "kind", target.kind, "repository", repository)
+		}
+	}
+
+	return reconcile.Result{}, nil
 }
 
-// TODO would remove any Github Action DependaBot secret if the CR is deleted
-//
-//lint:ignore U1000 Ignore could be used in the future to cleanup secrets
-func (r *GithubSecretReconciler) finalize(ctx context.Context, log logr.Logger, instance *secretv1alpha1.GithubSecret) error {
-	ke := client.ObjectKey{
-		Name:      "github-secret-operator",
-		Namespace: GithubSecretOperatorNamespace,
+// desiredSecrets returns the kind and name of every secret of the spec in the format used
+// by the SecretStatus entries.
+func desiredSecrets(spec secretv1alpha1.GithubSecretSpec) map[string]bool {
+	desired := map[string]bool{}
+	add := func(kind string, secrets []secretv1alpha1.Secrets) {
+		for _, secret := range secrets {
+			desired[secretStatusKey(kind, secret.Name)] = true
+		}
 	}
+	add("DependaBot", spec.DependaBotSecrets.Secrets)
+	add("Actions", spec.ActionsSecrets.Secrets)
+	add("Codespaces", spec.CodespacesSecrets.Secrets)
+	for environment, env := range spec.Environments {
+		add(environmentKind(environment), env.Secrets)
+	}
+	return desired
+}
 
-	akSecret := v1.Secret{}
-	err := r.Get(ctx, ke, &akSecret)
-	if err != nil {
-		return err
+func environmentKind(environment string) string {
+	return fmt.Sprintf("Environment %s", environment)
+}
+
+func secretStatusKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// findSecretStatus returns the recorded status of the secret or nil if the operator didn't push it yet.
+func findSecretStatus(secrets []secretv1alpha1.SecretStatus, kind, name string) *secretv1alpha1.SecretStatus {
+	for i := range secrets {
+		if secrets[i].Kind == kind && secrets[i].Name == name {
+			return &secrets[i]
+		}
 	}
+	return nil
+}
+
+// clockSkew tolerates differences between the clocks of Github and the cluster, a secret updated
+// within it after the operator pushed it is considered unchanged.
+const clockSkew = time.Minute
 
-	log.Info("Read Configuration Github Secret Operator", "Secret", akSecret)
+// modifiedInGithub tells whether the secret was updated in Github after the operator pushed it, for
+// example by someone in the UI. Github doesn't return the value, so only the time can be compared.
+func modifiedInGithub(secret *github.Secret, status *secretv1alpha1.SecretStatus) bool {
+	if secret == nil || status == nil || status.LastUpdated == nil {
+		return false
+	}
+	return secret.UpdatedAt.After(status.LastUpdated.Add(clockSkew))
+}
 
-	for k, v := range akSecret.Data {
-		_ = os.Setenv(k, string(v))
+// setSecretStatus adds or replaces the recorded status of the secret.
+func setSecretStatus(secrets *[]secretv1alpha1.SecretStatus, status secretv1alpha1.SecretStatus) {
+	if current := findSecretStatus(*secrets, status.Kind, status.Name); current != nil {
+		*current = status
+		return
 	}
+	*secrets = append(*secrets, status)
+}
 
-	cfg, ctx := config.Configure()
+func desiredVariables(spec secretv1alpha1.GithubSecretSpec) map[string]bool {
+	desired := map[string]bool{}
+	add := func(kind string, variables []secretv1alpha1.Variable) {
+		for _, variable := range variables {
+			desired[secretStatusKey(kind, variable.Name)] = true
+		}
+	}
+	add("Repository", spec.Variables)
+	for environment, env := range spec.Environments {
+		add(environmentKind(environment), env.Variables)
+	}
+	return desired
+}
 
-	gh := github.NewClient(cfg, github.WithContext(ctx))
-	for _, v := range instance.Spec.DependaBotSecrets.Secrets {
-		err := gh.RemoveDependaBotSecrets(instance.Spec.Repository, v.Name)
-		if err != nil {
-			log.Error(err, "Remove DependaBot Secrect", "Repo", instance.Spec.Repository, "Secret", v.Name)
+// findVariableStatus returns the recorded status of the variable or nil if the operator didn't write it.
+func findVariableStatus(variables []secretv1alpha1.VariableStatus, kind, name string) *secretv1alpha1.VariableStatus {
+	for i := range variables {
+		if variables[i].Kind == kind && variables[i].Name == name {
+			return &variables[i]
 		}
 	}
-	log.Info("Successfully removed Github Secrets")
 	return nil
 }
 
+// setVariableStatus records the variable unless it's recorded already.
+func setVariableStatus(variables *[]secretv1alpha1.VariableStatus, status secretv1alpha1.VariableStatus) {
+	if findVariableStatus(*variables, status.Kind, status.Name) == nil {
+		*variables = append(*variables, status)
+	}
+}
+
+// retainVariableStatus drops the variables that were removed from the spec from the status.
+func retainVariableStatus(variables []secretv1alpha1.VariableStatus, desired map[string]bool) []secretv1alpha1.VariableStatus {
+	var retained []secretv1alpha1.VariableStatus
+	for _, variable := range variables {
+		if desired[secretStatusKey(variable.Kind, variable.Name)] {
+			retained = append(retained, variable)
+		}
+	}
+	return retained
+}
+
+// conflictPolicy returns the policy for a secret that exists in Github but isn't recorded in the status,
+// the one of the secret if it's set, otherwise the one of the custom resource.
+func conflictPolicy(policy string, secret secretv1alpha1.Secrets) string {
+	if secret.ConflictPolicy != "" {
+		return secret.ConflictPolicy
+	}
+	if policy != "" {
+		return policy
+	}
+	return secretv1alpha1.ConflictPolicyOverwrite
+}
+
+// setConflictCondition reports a secret that exists in Github but wasn't pushed by the operator.
+// All conflicts of a reconcile pass are reported by the same condition.
+func setConflictCondition(conditions *[]metav1.Condition, kind, name string, generation int64) {
+	msg := fmt.Sprintf("%s secret %s already exists in Github and wasn't pushed by the operator", kind, name)
+	if current := apimeta.FindStatusCondition(*conditions, secretv1alpha1.ConditionTypeSecretConflict); current != nil {
+		msg = current.Message + "; " + msg
+	}
+	apimeta.SetStatusCondition(conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeSecretConflict, generation))
+}
+
+// retainSecretStatus drops the recorded status of the secrets that were removed from the spec.
+func retainSecretStatus(secrets []secretv1alpha1.SecretStatus, desired map[string]bool) []secretv1alpha1.SecretStatus {
+	var retained []secretv1alpha1.SecretStatus
+	for _, secret := range secrets {
+		if desired[secretStatusKey(secret.Kind, secret.Name)] {
+			retained = append(retained, secret)
+		}
+	}
+	return retained
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *GithubSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &secretv1alpha1.GithubSecret{}, secretRefIndex, func(obj client.Object) []string {
+		instance := obj.(*secretv1alpha1.GithubSecret)
+		return kubernetesSecretRefs(instance.GetNamespace(), specSecretRefs(*instance.GetSpec()))
+	})
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &secretv1alpha1.GithubSecret{}, configMapRefIndex, func(obj client.Object) []string {
+		instance := obj.(*secretv1alpha1.GithubSecret)
+		return configMapRefs(instance.GetNamespace(), specSecretRefs(*instance.GetSpec()))
+	})
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretv1alpha1.GithubSecret{}).
-		// WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})).
+		// status updates don't change the generation, this avoids reconciling again right after every pass
+		For(&secretv1alpha1.GithubSecret{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		// push the secrets again as soon as a referenced Kubernetes Secret or SOPS ConfigMap changes
+		Watches(&v1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsFor(secretRefIndex))).
+		Watches(&v1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.requestsFor(configMapRefIndex))).
 		Complete(r)
 }
 
+// requestsFor returns a function that maps a Kubernetes Secret or ConfigMap to the GithubSecrets
+// referencing it according to the index.
+func (r *GithubSecretReconciler) requestsFor(index string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		instances := &secretv1alpha1.GithubSecretList{}
+		err := r.List(ctx, instances, client.InNamespace(obj.GetNamespace()), client.MatchingFields{index: fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())})
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to list GithubSecrets referencing object", "object", obj.GetName())
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(instances.Items))
+		for _, instance := range instances.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&instance)})
+		}
+		return requests
+	}
+}
+
+// specSecretRefs returns the secret references of all secrets and variables of the spec.
+func specSecretRefs(spec secretv1alpha1.GithubSecretSpec) []secretv1alpha1.SecretRef {
+	refs := secretRefs(spec.DependaBotSecrets.Secrets)
+	refs = append(refs, secretRefs(spec.ActionsSecrets.Secrets)...)
+	refs = append(refs, secretRefs(spec.CodespacesSecrets.Secrets)...)
+	refs = append(refs, variableRefs(spec.Variables)...)
+	for _, env := range spec.Environments {
+		refs = append(refs, secretRefs(env.Secrets)...)
+		refs = append(refs, variableRefs(env.Variables)...)
+	}
+	return refs
+}
+
 func Condition(status metav1.ConditionStatus, msg string, reason string, generation int64) metav1.Condition {
 	return metav1.Condition{
 		Status:             status,
@@ -207,3 +609,55 @@ func Condition(status metav1.ConditionStatus, msg string, reason string, generat
 func FailedCondition(msg string, reason string, generation int64) metav1.Condition {
 	return Condition(metav1.ConditionFalse, msg, reason, generation)
 }
+
+// errorConditionTypes are the conditions reporting the failures of a reconcile pass.
+var errorConditionTypes = []string{
+	secretv1alpha1.ConditionTypeGCPSecretManagerError,
+	secretv1alpha1.ConditionTypeKubernetesSecretError,
+	secretv1alpha1.ConditionTypeVaultError,
+	secretv1alpha1.ConditionTypeAWSError,
+	secretv1alpha1.ConditionTypeAzureKeyVaultError,
+	secretv1alpha1.ConditionTypeSOPSError,
+	secretv1alpha1.ConditionTypeTemplateError,
+	secretv1alpha1.ConditionTypeSecretPropertyError,
+	secretv1alpha1.ConditionTypeGeneratedSecretError,
+	secretv1alpha1.ConditionTypeUnknownSecretSource,
+	secretv1alpha1.ConditionTypeSecretConflict,
+	secretv1alpha1.ConditionTypeGithubActionSecretError,
+}
+
+// resetErrorConditions removes the failures of the previous reconcile pass so that the status
+// only reports the failures of the current one. The removed conditions are returned to keep the
+// transition time of failures that still persist.
+func resetErrorConditions(conditions *[]metav1.Condition) []metav1.Condition {
+	var previous []metav1.Condition
+	for _, conditionType := range errorConditionTypes {
+		if condition := apimeta.FindStatusCondition(*conditions, conditionType); condition != nil {
+			previous = append(previous, *condition)
+			apimeta.RemoveStatusCondition(conditions, conditionType)
+		}
+	}
+	return previous
+}
+
+// setReadyCondition sets the Ready condition according to the failures of the current reconcile pass.
+func setReadyCondition(conditions *[]metav1.Condition, previous []metav1.Condition, name string, generation int64) {
+	for _, condition := range previous {
+		if current := apimeta.FindStatusCondition(*conditions, condition.Type); current != nil && current.Status == condition.Status {
+			current.LastTransitionTime = condition.LastTransitionTime
+		}
+	}
+
+	var failures []string
+	for _, conditionType := range errorConditionTypes {
+		if apimeta.FindStatusCondition(*conditions, conditionType) != nil {
+			failures = append(failures, conditionType)
+		}
+	}
+
+	if len(failures) > 0 {
+		apimeta.SetStatusCondition(conditions, Condition(metav1.ConditionFalse, fmt.Sprintf("%s not in ready state, failed with %s", name, strings.Join(failures, ", ")), secretv1alpha1.ConditionTypeReady, generation))
+		return
+	}
+	apimeta.SetStatusCondition(conditions, Condition(metav1.ConditionTrue, fmt.Sprintf("%s in ready state", name), secretv1alpha1.ConditionTypeReady, generation))
+}
@@ -42,7 +42,7 @@ var _ = Describe("CronJob controller", func() {
 					Repository: "repo",
 					DependaBotSecrets: secretv1alpha1.DependaBotSecrets{
 						Secrets: []secretv1alpha1.Secrets{
-							{Key: "key", Name: "name", Source: "source"},
+							{Name: "name", SecretRef: secretv1alpha1.SecretRef{Key: "key", Source: secretv1alpha1.SecretSourceGCP}},
 						},
 					},
 				},
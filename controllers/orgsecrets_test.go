@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+// selectedOrgSecret returns a GithubOrgSecret whose secret was pushed at the given time and is
+// selected for the repositories a and b.
+func selectedOrgSecret(pushed time.Time) *secretv1alpha1.GithubOrgSecret {
+	lastUpdated := metav1.NewTime(pushed)
+	return &secretv1alpha1.GithubOrgSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "org"},
+		Spec: secretv1alpha1.GithubOrgSecretSpec{
+			Organization: "test_org",
+			ActionsSecrets: secretv1alpha1.OrgSecrets{
+				Visibility:           secretv1alpha1.OrgSecretVisibilitySelected,
+				SelectedRepositories: []string{"a", "b"},
+				Secrets:              []secretv1alpha1.Secrets{{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}}},
+			},
+		},
+		Status: secretv1alpha1.GithubOrgSecretStatus{Secrets: []secretv1alpha1.SecretStatus{
+			{Kind: "Actions", Name: "TOKEN", Version: "1", LastUpdated: &lastUpdated},
+		}},
+	}
+}
+
+func newOrgReconciler(pushed time.Time, selected []int64, put http.HandlerFunc) *GithubOrgSecretReconciler {
+	var repositories []*gogithub.Repository
+	for _, id := range selected {
+		repositories = append(repositories, &gogithub.Repository{ID: gogithub.Int64(id)})
+	}
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetOrgsActionsSecretsByOrg,
+			gogithub.Secrets{TotalCount: 1, Secrets: []*gogithub.Secret{
+				{Name: "TOKEN", Visibility: secretv1alpha1.OrgSecretVisibilitySelected, UpdatedAt: gogithub.Timestamp{Time: pushed}},
+			}},
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ids := map[string]int64{"a": 1, "b": 2}
+				name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+				_, _ = w.Write(mock.MustMarshal(gogithub.Repository{ID: gogithub.Int64(ids[name])}))
+			}),
+		),
+		mock.WithRequestMatch(
+			mock.GetOrgsActionsSecretsRepositoriesByOrgBySecretName,
+			gogithub.SelectedReposList{TotalCount: gogithub.Int(len(repositories)), Repositories: repositories},
+		),
+		mock.WithRequestMatchHandler(mock.PutOrgsActionsSecretsRepositoriesByOrgBySecretName, put),
+	)
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"token": "value"})
+	return &GithubOrgSecretReconciler{
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: sources,
+		Config:  config.Config{Owner: "fr123k"},
+	}
+}
+
+func TestReconcileOrgSecretsKeepsSelectedRepositories(t *testing.T) {
+	pushed := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	var calls []string
+	// the repositories are selected already, in a different order
+	r := newOrgReconciler(pushed, []int64{2, 1}, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	})
+	instance := selectedOrgSecret(pushed)
+
+	_, err := r.reconcileOrganization(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	assert.Empty(t, calls)
+	assert.Nil(t, apimeta.FindStatusCondition(instance.Status.Conditions, secretv1alpha1.ConditionTypeGithubActionSecretError))
+}
+
+func TestReconcileOrgSecretsUpdatesSelectedRepositories(t *testing.T) {
+	pushed := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	var calls []string
+	// repository b was removed from the secret in Github
+	r := newOrgReconciler(pushed, []int64{1}, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	})
+	instance := selectedOrgSecret(pushed)
+
+	_, err := r.reconcileOrganization(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"PUT /orgs/test_org/actions/secrets/TOKEN/repositories"}, calls)
+	assert.Nil(t, apimeta.FindStatusCondition(instance.Status.Conditions, secretv1alpha1.ConditionTypeGithubActionSecretError))
+}
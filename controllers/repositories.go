@@ -0,0 +1,262 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v54/github"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+// reconcileRepositories reconciles every repository of the spec on its own, so a failing repository
+// doesn't block the others. The failures are reported in the status of each repository and
+// summarized by the conditions of the GithubSecret.
+func (r *GithubSecretReconciler) reconcileRepositories(ctx context.Context, reqLogger logr.Logger, instance githubSecretObject) (ctrl.Result, error) {
+	repositories := specRepositories(*instance.GetSpec())
+	// complete is false if the selected repositories are unknown, the ones of the previous pass are kept then
+	complete := true
+	if selector := instance.GetSpec().RepositorySelector; selector != nil {
+		selected, err := r.selectRepositories(r.specOwner(*instance.GetSpec()), *selector)
+		if err != nil {
+			msg := fmt.Sprintf("failed to select repositories. Error:%s", err.Error())
+			reqLogger.Error(err, msg)
+			apimeta.SetStatusCondition(&instance.GetStatus().Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+			complete = false
+		}
+		for _, repository := range selected {
+			if !slices.Contains(repositories, repository) {
+				repositories = append(repositories, repository)
+			}
+		}
+	} else if len(repositories) == 0 {
+		msg := "neither repository, repositories nor repositorySelector are set"
+		reqLogger.Info(msg)
+		apimeta.SetStatusCondition(&instance.GetStatus().Conditions, FailedCondition(msg, secretv1alpha1.ConditionTypeGithubActionSecretError, instance.GetGeneration()))
+	}
+
+	var result ctrl.Result
+	var errs []error
+	statuses := make([]secretv1alpha1.RepositoryStatus, 0, len(repositories))
+	for _, repository := range repositories {
+		repo := secretv1alpha1.RepositoryStatus{Name: repository}
+		if current := findRepositoryStatus(instance.GetStatus().Repositories, repository); current != nil {
+			repo = *current
+		}
+
+		previous := resetErrorConditions(&repo.Conditions)
+		repoResult, err := r.reconcileRepository(ctx, reqLogger.WithValues("repository", repository), instance, &repo)
+		setReadyCondition(&repo.Conditions, previous, fmt.Sprintf("Repository %s", repository), instance.GetGeneration())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("repository %s: %w", repository, err))
+			if result.IsZero() {
+				result = repoResult
+			}
+		}
+		statuses = append(statuses, repo)
+	}
+
+	for _, repo := range instance.GetStatus().Repositories {
+		if findRepositoryStatus(statuses, repo.Name) != nil {
+			continue
+		}
+		if !complete {
+			// the repository may still be selected
+			statuses = append(statuses, repo)
+			continue
+		}
+		if !instance.GetSpec().Prune {
+			continue
+		}
+		// all secrets of a repository that was removed from the spec are pruned
+		previous := resetErrorConditions(&repo.Conditions)
+		desired := map[string]bool{}
+		r.pruneSecrets(reqLogger.WithValues("repository", repo.Name), instance, &repo, desired)
+		repo.Secrets = retainSecretStatus(repo.Secrets, desired)
+		setReadyCondition(&repo.Conditions, previous, fmt.Sprintf("Repository %s", repo.Name), instance.GetGeneration())
+		if len(repo.Secrets) > 0 {
+			// keep the secrets that couldn't be removed to retry on the next pass
+			statuses = append(statuses, repo)
+		}
+	}
+
+	instance.GetStatus().Repositories = statuses
+	summarizeRepositoryConditions(&instance.GetStatus().Conditions, statuses, instance.GetGeneration())
+	return result, errors.Join(errs...)
+}
+
+// specRepositories returns the repository and repositories of the spec without duplicates.
+func specRepositories(spec secretv1alpha1.GithubSecretSpec) []string {
+	var repositories []string
+	seen := map[string]bool{}
+	for _, repository := range append([]string{spec.Repository}, spec.Repositories...) {
+		if repository == "" || seen[repository] {
+			continue
+		}
+		seen[repository] = true
+		repositories = append(repositories, repository)
+	}
+	return repositories
+}
+
+// specOwner returns the owner of the spec or the configured one if it isn't set.
+func (r *GithubSecretReconciler) specOwner(spec secretv1alpha1.GithubSecretSpec) string {
+	if spec.Owner != "" {
+		return spec.Owner
+	}
+	return r.Config.Owner
+}
+
+// repositoryOwner splits a repository given as owner/repository into its owner and name,
+// repositories without an owner belong to the owner of the spec.
+func (r *GithubSecretReconciler) repositoryOwner(spec secretv1alpha1.GithubSecretSpec, repository string) (string, string) {
+	if owner, name, ok := strings.Cut(repository, "/"); ok {
+		return owner, name
+	}
+	return r.specOwner(spec), repository
+}
+
+// selectRepositories returns the names of the repositories of the owner that match the selector.
+func (r *GithubSecretReconciler) selectRepositories(owner string, selector secretv1alpha1.RepositorySelector) ([]string, error) {
+	var name *regexp.Regexp
+	if selector.Name != "" {
+		var err error
+		name, err = regexp.Compile(selector.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name %s of the repository selector. Error:%w", selector.Name, err)
+		}
+	}
+
+	repositories, err := r.Github.ListRepositories(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, repository := range repositories {
+		if matchesSelector(selector, name, repository) {
+			selected = append(selected, repository.GetName())
+		}
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// matchesSelector tells whether the repository matches all criteria of the selector.
+func matchesSelector(selector secretv1alpha1.RepositorySelector, name *regexp.Regexp, repository *gogithub.Repository) bool {
+	if repository.GetArchived() != selector.Archived {
+		return false
+	}
+	if selector.Visibility != "" && repositoryVisibility(repository) != selector.Visibility {
+		return false
+	}
+	if name != nil && !name.MatchString(repository.GetName()) {
+		return false
+	}
+	for _, topic := range selector.Topics {
+		if !slices.Contains(repository.Topics, topic) {
+			return false
+		}
+	}
+	return true
+}
+
+// repositoryVisibility returns the visibility of the repository, older Github Enterprise
+// versions only report whether it's private.
+func repositoryVisibility(repository *gogithub.Repository) string {
+	if repository.Visibility != nil {
+		return repository.GetVisibility()
+	}
+	if repository.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
+// findRepositoryStatus returns the status of the repository or nil if it wasn't reconciled yet.
+func findRepositoryStatus(repositories []secretv1alpha1.RepositoryStatus, name string) *secretv1alpha1.RepositoryStatus {
+	for i := range repositories {
+		if repositories[i].Name == name {
+			return &repositories[i]
+		}
+	}
+	return nil
+}
+
+// legacyConditionTypeGithubActionSecretError is the type of the GithubActionSecretError condition
+// before its trailing space was removed.
+const legacyConditionTypeGithubActionSecretError = "GithubActionSecretError "
+
+// migrateSecretStatus moves the secrets recorded before the status was kept per repository
+// to the status of the repository they were pushed to. It also removes the condition of the
+// legacy type, which would otherwise never be updated again.
+func migrateSecretStatus(status *secretv1alpha1.GithubSecretStatus, repository string) {
+	apimeta.RemoveStatusCondition(&status.Conditions, legacyConditionTypeGithubActionSecretError)
+	if len(status.Secrets) == 0 || repository == "" {
+		return
+	}
+	if findRepositoryStatus(status.Repositories, repository) == nil {
+		status.Repositories = append(status.Repositories, secretv1alpha1.RepositoryStatus{Name: repository, Secrets: status.Secrets})
+	}
+	status.Secrets = nil
+}
+
+// summarizeRepositoryConditions sets the failures of the repositories as conditions of the
+// custom resource, the messages are prefixed with the name of the failing repository.
+func summarizeRepositoryConditions(conditions *[]metav1.Condition, repositories []secretv1alpha1.RepositoryStatus, generation int64) {
+	for _, conditionType := range errorConditionTypes {
+		var messages []string
+		if current := apimeta.FindStatusCondition(*conditions, conditionType); current != nil {
+			messages = append(messages, current.Message)
+		}
+		for _, repo := range repositories {
+			if condition := apimeta.FindStatusCondition(repo.Conditions, conditionType); condition != nil {
+				messages = append(messages, fmt.Sprintf("%s: %s", repo.Name, condition.Message))
+			}
+		}
+		if len(messages) > 0 {
+			apimeta.SetStatusCondition(conditions, FailedCondition(strings.Join(messages, "; "), conditionType, generation))
+		}
+	}
+}
+
+// repositorySecrets returns the secrets recorded for the repository.
+func repositorySecrets(status secretv1alpha1.GithubSecretStatus, repository string) []secretv1alpha1.SecretStatus {
+	if repo := findRepositoryStatus(status.Repositories, repository); repo != nil {
+		return repo.Secrets
+	}
+	return nil
+}
+
+// repositoryVariables returns the variables recorded for the repository.
+func repositoryVariables(status secretv1alpha1.GithubSecretStatus, repository string) []secretv1alpha1.VariableStatus {
+	if repo := findRepositoryStatus(status.Repositories, repository); repo != nil {
+		return repo.Variables
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+func TestReconcileRepositories(t *testing.T) {
+	var calls []string
+	key, keyID := "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=", "test_key_id"
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsSecretsByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/failing/") {
+					mock.WriteError(w, http.StatusInternalServerError, "github went belly up or something")
+					return
+				}
+				_, _ = w.Write(mock.MustMarshal(gogithub.Secrets{}))
+			}),
+		),
+		mock.WithRequestMatch(mock.GetReposActionsSecretsPublicKeyByOwnerByRepo, gogithub.PublicKey{Key: &key, KeyID: &keyID}),
+		mock.WithRequestMatchHandler(
+			mock.PutReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+			}),
+		),
+	)
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"token": "value"})
+	r := &GithubSecretReconciler{
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: sources,
+		Config:  config.Config{Owner: "fr123k"},
+	}
+
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repositories: []string{"failing", "test_repo", "failing"},
+			Prune:        true,
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+			}},
+		},
+		Status: secretv1alpha1.GithubSecretStatus{Repositories: []secretv1alpha1.RepositoryStatus{
+			{Name: "removed", Secrets: []secretv1alpha1.SecretStatus{{Kind: "Actions", Name: "TOKEN", Version: "1"}}},
+		}},
+	}
+
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.ErrorContains(t, err, "repository failing")
+
+	// the failing repository doesn't block the other one, the removed one is pruned
+	assert.Equal(t, []string{
+		"PUT /repos/fr123k/test_repo/actions/secrets/TOKEN",
+		"DELETE /repos/fr123k/removed/actions/secrets/TOKEN",
+	}, calls)
+	assert.Len(t, instance.Status.Repositories, 2)
+
+	failing := findRepositoryStatus(instance.Status.Repositories, "failing")
+	assert.False(t, apimeta.IsStatusConditionTrue(failing.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.Empty(t, failing.Secrets)
+
+	repo := findRepositoryStatus(instance.Status.Repositories, "test_repo")
+	assert.True(t, apimeta.IsStatusConditionTrue(repo.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.NotNil(t, findSecretStatus(repo.Secrets, "Actions", "TOKEN"))
+
+	condition := apimeta.FindStatusCondition(instance.Status.Conditions, secretv1alpha1.ConditionTypeGithubActionSecretError)
+	assert.True(t, strings.HasPrefix(condition.Message, "failing: failed to list Actions secrets"))
+}
+
+func TestReconcileRepositoriesOfOwner(t *testing.T) {
+	var calls []string
+	key, keyID := "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=", "test_key_id"
+	record := func(response interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			_, _ = w.Write(mock.MustMarshal(response))
+		}
+	}
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(mock.GetReposActionsSecretsByOwnerByRepo, record(gogithub.Secrets{})),
+		mock.WithRequestMatchHandler(mock.GetReposActionsSecretsPublicKeyByOwnerByRepo, record(gogithub.PublicKey{Key: &key, KeyID: &keyID})),
+		mock.WithRequestMatchHandler(mock.PutReposActionsSecretsByOwnerByRepoBySecretName, record(nil)),
+	)
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"token": "value"})
+	r := &GithubSecretReconciler{
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: sources,
+		Config:  config.Config{Owner: "fr123k"},
+	}
+
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Owner:        "payments",
+			Repositories: []string{"api", "search/web"},
+			ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+				{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+			}},
+		},
+	}
+
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	// none of the calls use the configured owner
+	assert.Equal(t, []string{
+		"GET /repos/payments/api/actions/secrets",
+		"GET /repos/payments/api/actions/secrets/public-key",
+		"PUT /repos/payments/api/actions/secrets/TOKEN",
+		"GET /repos/search/web/actions/secrets",
+		"GET /repos/search/web/actions/secrets/public-key",
+		"PUT /repos/search/web/actions/secrets/TOKEN",
+	}, calls)
+	assert.NotNil(t, findRepositoryStatus(instance.Status.Repositories, "search/web"))
+}
+
+func TestRepositoryOwner(t *testing.T) {
+	r := &GithubSecretReconciler{Config: config.Config{Owner: "fr123k"}}
+
+	for _, test := range []struct {
+		spec       secretv1alpha1.GithubSecretSpec
+		repository string
+		owner      string
+		name       string
+	}{
+		{secretv1alpha1.GithubSecretSpec{}, "app", "fr123k", "app"},
+		{secretv1alpha1.GithubSecretSpec{Owner: "payments"}, "app", "payments", "app"},
+		{secretv1alpha1.GithubSecretSpec{Owner: "payments"}, "search/app", "search", "app"},
+	} {
+		owner, name := r.repositoryOwner(test.spec, test.repository)
+		assert.Equal(t, test.owner, owner)
+		assert.Equal(t, test.name, name)
+	}
+}
+
+func TestSpecRepositories(t *testing.T) {
+	spec := secretv1alpha1.GithubSecretSpec{Repository: "app", Repositories: []string{"api", "app", "", "web"}}
+	assert.Equal(t, []string{"app", "api", "web"}, specRepositories(spec))
+	assert.Empty(t, specRepositories(secretv1alpha1.GithubSecretSpec{}))
+}
+
+func TestMigrateSecretStatus(t *testing.T) {
+	secrets := []secretv1alpha1.SecretStatus{{Kind: "Actions", Name: "TOKEN", Version: "1"}}
+	status := secretv1alpha1.GithubSecretStatus{Secrets: secrets}
+
+	migrateSecretStatus(&status, "app")
+	assert.Nil(t, status.Secrets)
+	assert.Equal(t, []secretv1alpha1.RepositoryStatus{{Name: "app", Secrets: secrets}}, status.Repositories)
+
+	// migrating again doesn't change anything
+	migrateSecretStatus(&status, "app")
+	assert.Equal(t, []secretv1alpha1.RepositoryStatus{{Name: "app", Secrets: secrets}}, status.Repositories)
+}
+
+func TestMigrateLegacyCondition(t *testing.T) {
+	status := secretv1alpha1.GithubSecretStatus{Conditions: []metav1.Condition{
+		{Type: "GithubActionSecretError ", Status: metav1.ConditionTrue},
+		{Type: secretv1alpha1.ConditionTypeReady, Status: metav1.ConditionTrue},
+	}}
+
+	migrateSecretStatus(&status, "app")
+	assert.Nil(t, apimeta.FindStatusCondition(status.Conditions, "GithubActionSecretError "))
+	assert.NotNil(t, apimeta.FindStatusCondition(status.Conditions, secretv1alpha1.ConditionTypeReady))
+}
+
+func TestSelectRepositories(t *testing.T) {
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsReposByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(mock.MustMarshal([]gogithub.Repository{
+					{Name: gogithub.String("payments-api"), Topics: []string{"team-payments", "go"}, Visibility: gogithub.String("private")},
+					{Name: gogithub.String("payments-web"), Topics: []string{"team-payments"}, Visibility: gogithub.String("public")},
+					{Name: gogithub.String("payments-legacy"), Topics: []string{"team-payments"}, Private: gogithub.Bool(true), Archived: gogithub.Bool(true)},
+					{Name: gogithub.String("search-api"), Topics: []string{"team-search", "go"}, Private: gogithub.Bool(true)},
+				}))
+			}),
+		),
+	)
+	r := &GithubSecretReconciler{
+		Github: github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Config: config.Config{Owner: "fr123k"},
+	}
+
+	for _, test := range []struct {
+		selector secretv1alpha1.RepositorySelector
+		expected []string
+	}{
+		{secretv1alpha1.RepositorySelector{Topics: []string{"team-payments"}}, []string{"payments-api", "payments-web"}},
+		{secretv1alpha1.RepositorySelector{Topics: []string{"team-payments"}, Archived: true}, []string{"payments-legacy"}},
+		{secretv1alpha1.RepositorySelector{Topics: []string{"go"}, Visibility: "private"}, []string{"payments-api", "search-api"}},
+		{secretv1alpha1.RepositorySelector{Name: "-api$"}, []string{"payments-api", "search-api"}},
+		{secretv1alpha1.RepositorySelector{Name: "^payments-", Visibility: "public"}, []string{"payments-web"}},
+		{secretv1alpha1.RepositorySelector{Topics: []string{"team-payments", "go"}}, []string{"payments-api"}},
+		{secretv1alpha1.RepositorySelector{Topics: []string{"team-unknown"}}, nil},
+	} {
+		selected, err := r.selectRepositories("fr123k", test.selector)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, selected, "selector %+v", test.selector)
+	}
+
+	_, err := r.selectRepositories("fr123k", secretv1alpha1.RepositorySelector{Name: "payments-("})
+	assert.ErrorContains(t, err, "invalid name payments-( of the repository selector")
+}
+
+func TestSelectPrivateRepositoriesOfUser(t *testing.T) {
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsReposByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mock.WriteError(w, http.StatusNotFound, "Not Found")
+			}),
+		),
+		mock.WithRequestMatch(mock.GetUser, gogithub.User{Login: gogithub.String("fr123k")}),
+		mock.WithRequestMatch(mock.GetUserRepos, []gogithub.Repository{
+			{Name: gogithub.String("dotfiles"), Private: gogithub.Bool(false)},
+			{Name: gogithub.String("infrastructure"), Private: gogithub.Bool(true)},
+		}),
+	)
+	r := &GithubSecretReconciler{
+		Github: github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Config: config.Config{Owner: "fr123k"},
+	}
+
+	selected, err := r.selectRepositories("fr123k", secretv1alpha1.RepositorySelector{Visibility: "private"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"infrastructure"}, selected)
+}
+
+func TestReconcileRepositoriesKeepsSelectedOnFailure(t *testing.T) {
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsReposByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mock.WriteError(w, http.StatusInternalServerError, "github went belly up or something")
+			}),
+		),
+	)
+	r := &GithubSecretReconciler{
+		Github: github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Config: config.Config{Owner: "fr123k"},
+	}
+	selected := secretv1alpha1.RepositoryStatus{Name: "payments-api", Secrets: []secretv1alpha1.SecretStatus{{Kind: "Actions", Name: "TOKEN", Version: "1"}}}
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			RepositorySelector: &secretv1alpha1.RepositorySelector{Topics: []string{"team-payments"}},
+			Prune:              true,
+		},
+		Status: secretv1alpha1.GithubSecretStatus{Repositories: []secretv1alpha1.RepositoryStatus{selected}},
+	}
+
+	// no secrets are pruned from the repositories selected before
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+	assert.Equal(t, []secretv1alpha1.RepositoryStatus{selected}, instance.Status.Repositories)
+
+	condition := apimeta.FindStatusCondition(instance.Status.Conditions, secretv1alpha1.ConditionTypeGithubActionSecretError)
+	assert.True(t, strings.HasPrefix(condition.Message, "failed to select repositories"))
+}
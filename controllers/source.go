@@ -0,0 +1,165 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+// secretRefIndex indexes the custom resources by the Kubernetes Secrets they reference
+// in the format <namespace>/<name>, so that changes of a Secret can be mapped to them.
+const secretRefIndex = ".spec.secretRefs"
+
+// configMapRefIndex indexes the custom resources by the ConfigMaps with the SOPS encrypted
+// documents they reference, in the same format as the secretRefIndex.
+const configMapRefIndex = ".spec.configMapRefs"
+
+// kubernetesSecretRefs returns the Kubernetes Secrets referenced by the secret references, including
+// the ones storing generated values, in the format used by the secretRefIndex.
+func kubernetesSecretRefs(namespace string, refs []secretv1alpha1.SecretRef) []string {
+	return namespacedRefs(namespace, refs, func(ref secretv1alpha1.SecretRef) string {
+		if ref.Source != secretv1alpha1.SecretSourceKubernetes && ref.Source != secretv1alpha1.SecretSourceGenerated {
+			return ""
+		}
+		return ref.SecretName
+	})
+}
+
+// configMapRefs returns the ConfigMaps referenced by the SOPS secret references
+// in the format used by the configMapRefIndex.
+func configMapRefs(namespace string, refs []secretv1alpha1.SecretRef) []string {
+	return namespacedRefs(namespace, refs, func(ref secretv1alpha1.SecretRef) string {
+		if ref.Source != secretv1alpha1.SecretSourceSOPS || ref.Encrypted != "" {
+			return ""
+		}
+		return ref.ConfigMapName
+	})
+}
+
+// namespacedRefs returns the distinct <namespace>/<name> of the objects the references point to,
+// name returns the name of the object or an empty string for references to other objects.
+func namespacedRefs(namespace string, refs []secretv1alpha1.SecretRef, name func(secretv1alpha1.SecretRef) string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if name(ref) == "" {
+			continue
+		}
+		ns := namespace
+		if ns == "" {
+			ns = ref.Namespace
+		}
+		key := fmt.Sprintf("%s/%s", ns, name(ref))
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// variableRefs returns the secret references of the variables that read their value from a source.
+func variableRefs(variables []secretv1alpha1.Variable) []secretv1alpha1.SecretRef {
+	var refs []secretv1alpha1.SecretRef
+	for _, variable := range variables {
+		if variable.ValueFrom != nil {
+			refs = append(refs, *variable.ValueFrom)
+		}
+	}
+	return refs
+}
+
+// secretRefs returns the secret references of the secrets, for templates the ones of their inputs.
+func secretRefs(secrets []secretv1alpha1.Secrets) []secretv1alpha1.SecretRef {
+	refs := make([]secretv1alpha1.SecretRef, 0, len(secrets))
+	for _, secret := range secrets {
+		if secret.Template == "" {
+			refs = append(refs, secret.SecretRef)
+			continue
+		}
+		for _, name := range inputNames(secret.Inputs) {
+			refs = append(refs, secret.Inputs[name])
+		}
+	}
+	return refs
+}
+
+// inputNames returns the sorted names of the template inputs, so they're always read in the same order.
+func inputNames(inputs map[string]secretv1alpha1.SecretRef) []string {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// secretValue reads the value of the secret from its source, or renders its template with the values
+// of the inputs. On failure it also returns the type of the condition reporting it.
+func secretValue(ctx context.Context, sources *source.Registry, namespace string, secret secretv1alpha1.Secrets) (*source.Secret, string, error) {
+	if secret.Template == "" {
+		value, err := sources.Get(ctx, namespace, secret.SecretRef)
+		if err != nil {
+			return nil, errorConditionType(sources, secret.SecretRef, err), err
+		}
+		return value, "", nil
+	}
+
+	inputs := make(map[string]*source.Secret, len(secret.Inputs))
+	for _, name := range inputNames(secret.Inputs) {
+		ref := secret.Inputs[name]
+		value, err := sources.Get(ctx, namespace, ref)
+		if err != nil {
+			return nil, errorConditionType(sources, ref, err), fmt.Errorf("failed to read input %s of secret %s. Error:%w", name, secret.Name, err)
+		}
+		inputs[name] = value
+	}
+	value, err := source.Render(secret.Name, secret.Template, inputs)
+	if err != nil {
+		return nil, secretv1alpha1.ConditionTypeTemplateError, err
+	}
+	return value, "", nil
+}
+
+// variableValue returns the literal value of the variable or reads it from its secret source.
+func variableValue(ctx context.Context, sources *source.Registry, namespace string, variable secretv1alpha1.Variable) (string, error) {
+	if variable.ValueFrom == nil {
+		return variable.Value, nil
+	}
+
+	value, err := sources.Get(ctx, namespace, *variable.ValueFrom)
+	if err != nil {
+		return "", err
+	}
+	return value.Value, nil
+}
+
+// errorConditionType returns the type of the condition reporting the failure to read the reference.
+// Properties that can't be resolved are reported separately from the failures of the source.
+func errorConditionType(sources *source.Registry, ref secretv1alpha1.SecretRef, err error) string {
+	var propertyErr *source.PropertyError
+	if errors.As(err, &propertyErr) {
+		return secretv1alpha1.ConditionTypeSecretPropertyError
+	}
+	return sources.ConditionType(ref)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+// staticSource returns the values of its map by key.
+type staticSource map[string]string
+
+func (s staticSource) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*source.Secret, error) {
+	value, ok := s[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s not found", ref.Key)
+	}
+	return &source.Secret{Value: value, Version: "1"}, nil
+}
+
+func TestKubernetesSecretRefs(t *testing.T) {
+	spec := secretv1alpha1.GithubSecretSpec{
+		ActionsSecrets: secretv1alpha1.ActionsSecrets{Secrets: []secretv1alpha1.Secrets{
+			{Name: "GCP", SecretRef: secretv1alpha1.SecretRef{Key: "gcp", Source: secretv1alpha1.SecretSourceGCP}},
+			{Name: "CERT", SecretRef: secretv1alpha1.SecretRef{Key: "tls.crt", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "tls"}},
+			{Name: "KEY", SecretRef: secretv1alpha1.SecretRef{Key: "tls.key", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "tls"}},
+		}},
+		Variables: []secretv1alpha1.Variable{
+			{Name: "DOMAIN", ValueFrom: &secretv1alpha1.SecretRef{Key: "domain", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "dns", Namespace: "ignored"}},
+		},
+	}
+
+	assert.Equal(t, []string{"default/tls", "default/dns"}, kubernetesSecretRefs("default", specSecretRefs(spec)))
+}
+
+func TestConfigMapRefs(t *testing.T) {
+	refs := []secretv1alpha1.SecretRef{
+		{Key: "token", Source: secretv1alpha1.SecretSourceSOPS, ConfigMapName: "secrets", ConfigMapKey: "secrets.yaml"},
+		{Key: "password", Source: secretv1alpha1.SecretSourceSOPS, ConfigMapName: "secrets", ConfigMapKey: "secrets.yaml"},
+		{Key: "inline", Source: secretv1alpha1.SecretSourceSOPS, Encrypted: "token: ENC[...]"},
+		{Key: "tls.crt", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "tls"},
+		{Key: "org", Source: secretv1alpha1.SecretSourceSOPS, ConfigMapName: "org-secrets", Namespace: "platform"},
+	}
+
+	assert.Equal(t, []string{"default/secrets", "default/org-secrets"}, configMapRefs("default", refs))
+	assert.Equal(t, []string{"/secrets", "platform/org-secrets"}, configMapRefs("", refs))
+}
+
+func TestSecretValueTemplate(t *testing.T) {
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"user": "robot", "password": "secret"})
+	secret := secretv1alpha1.Secrets{
+		Name:     "DSN",
+		Template: "postgres://{{ .user }}:{{ .password }}@db/app",
+		Inputs: map[string]secretv1alpha1.SecretRef{
+			"user":     {Key: "user"},
+			"password": {Key: "password"},
+		},
+	}
+
+	value, _, err := secretValue(context.Background(), sources, "default", secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://robot:secret@db/app", value.Value)
+
+	secret.Inputs["password"] = secretv1alpha1.SecretRef{Key: "missing"}
+	_, conditionType, err := secretValue(context.Background(), sources, "default", secret)
+	assert.EqualError(t, err, "failed to read input password of secret DSN. Error:secret missing not found")
+	assert.Equal(t, secretv1alpha1.ConditionTypeGCPSecretManagerError, conditionType)
+
+	secret.Inputs["password"] = secretv1alpha1.SecretRef{Key: "password"}
+	secret.Template = "{{ .host }}"
+	_, conditionType, err = secretValue(context.Background(), sources, "default", secret)
+	assert.Error(t, err)
+	assert.Equal(t, secretv1alpha1.ConditionTypeTemplateError, conditionType)
+}
+
+func TestSecretRefsOfTemplates(t *testing.T) {
+	secrets := []secretv1alpha1.Secrets{
+		{Name: "TOKEN", SecretRef: secretv1alpha1.SecretRef{Key: "token"}},
+		{Name: "DSN", Template: "{{ .user }}:{{ .password }}", Inputs: map[string]secretv1alpha1.SecretRef{
+			"user":     {Key: "user", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "db"},
+			"password": {Key: "password", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "db"},
+		}},
+	}
+
+	assert.Equal(t, []secretv1alpha1.SecretRef{
+		{Key: "token"},
+		{Key: "password", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "db"},
+		{Key: "user", Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "db"},
+	}, secretRefs(secrets))
+}
+
+func TestErrorConditionType(t *testing.T) {
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{})
+	ref := secretv1alpha1.SecretRef{Key: "db", Source: secretv1alpha1.SecretSourceGCP}
+
+	assert.Equal(t, secretv1alpha1.ConditionTypeGCPSecretManagerError, errorConditionType(sources, ref, fmt.Errorf("not found")))
+	assert.Equal(t, secretv1alpha1.ConditionTypeSecretPropertyError, errorConditionType(sources, ref, fmt.Errorf("failed to read input. Error:%w", &source.PropertyError{Err: fmt.Errorf("property password not found in secret db")})))
+}
@@ -43,6 +43,7 @@ import (
 	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
 	"github.com/fr123k/github-operator/pkg/config"
 	"github.com/fr123k/github-operator/pkg/gcloud"
+	"github.com/fr123k/github-operator/pkg/source"
 
 	//+kubebuilder:scaffold:imports
 
@@ -119,6 +120,18 @@ var _ = BeforeSuite(func() {
 			mock.GetReposDependabotSecretsPublicKeyByOwnerByRepo,
 			http.HandlerFunc(DependaBotPublicKey("aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
 		),
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsActionsSecretsByOrg,
+			http.HandlerFunc(DependaBotSecrets()),
+		),
+		mock.WithRequestMatchHandler(
+			mock.PutOrgsActionsSecretsByOrgBySecretName,
+			http.HandlerFunc(DependaBotSecrets()),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsActionsSecretsPublicKeyByOrg,
+			http.HandlerFunc(DependaBotPublicKey("aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
+		),
 	)
 	client := github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(mockedHTTPClient))
 
@@ -142,16 +155,37 @@ var _ = BeforeSuite(func() {
 			option.WithoutAuthentication(),
 			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials()))),
 	)
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, source.GCP{Client: gc})
+	sources.Register(secretv1alpha1.SecretSourceKubernetes, secretv1alpha1.ConditionTypeKubernetesSecretError, source.Kubernetes{Client: k8sManager.GetClient(), VersionKey: []byte("version-key")})
 
 	err = (&GithubSecretReconciler{
-		Client: k8sManager.GetClient(),
-		Scheme: k8sManager.GetScheme(),
-		Github: client,
-		GCloud: gc,
-		Config: cfg,
+		Client:  k8sManager.GetClient(),
+		Scheme:  k8sManager.GetScheme(),
+		Github:  client,
+		Sources: sources,
+		Config:  cfg,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&GithubOrgSecretReconciler{
+		Client:  k8sManager.GetClient(),
+		Scheme:  k8sManager.GetScheme(),
+		Github:  client,
+		Sources: sources,
+		Config:  cfg,
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ClusterGithubSecretReconciler{GithubSecretReconciler: GithubSecretReconciler{
+		Client:  k8sManager.GetClient(),
+		Scheme:  k8sManager.GetScheme(),
+		Github:  client,
+		Sources: sources,
+		Config:  cfg,
+	}}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
 	go func() {
 		err = k8sManager.Start(ctx)
 		Expect(err).ToNot(HaveOccurred(), "failed to run manager")
@@ -217,7 +251,7 @@ type fakeSecretManagerServer struct {
 
 func (f *fakeSecretManagerServer) AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
 	resp := &secretmanagerpb.AccessSecretVersionResponse{
-		Name: "projects/fr123k/secrets/secret/versions/latest",
+		Name: "projects/fr123k/secrets/secret/versions/1",
 		Payload: &secretmanagerpb.SecretPayload{
 			Data: []byte("secret"),
 		},
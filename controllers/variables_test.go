@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v54/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+)
+
+func TestReconcileVariables(t *testing.T) {
+	var calls []string
+	record := func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+	}
+	githubClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposActionsVariablesByOwnerByRepo,
+			gogithub.ActionsVariables{TotalCount: 2, Variables: []*gogithub.ActionsVariable{
+				{Name: "UNCHANGED", Value: "europe-west1"},
+				{Name: "CHANGED", Value: "old"},
+			}},
+		),
+		mock.WithRequestMatchHandler(mock.PostReposActionsVariablesByOwnerByRepo, http.HandlerFunc(record)),
+		mock.WithRequestMatchHandler(mock.PatchReposActionsVariablesByOwnerByRepoByName, http.HandlerFunc(record)),
+	)
+	r := &GithubSecretReconciler{
+		Github:  github.NewClient(config.Config{Owner: "fr123k"}, github.WithContext(context.Background()), github.WithClient(githubClient)),
+		Sources: source.NewRegistry(),
+		Config:  config.Config{Owner: "fr123k"},
+	}
+	instance := &secretv1alpha1.GithubSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: secretv1alpha1.GithubSecretSpec{
+			Repository: "test_repo",
+			Variables: []secretv1alpha1.Variable{
+				{Name: "UNCHANGED", Value: "europe-west1"},
+				{Name: "CHANGED", Value: "new"},
+				{Name: "MISSING", Value: "value"},
+			},
+		},
+	}
+
+	_, err := r.reconcileRepositories(context.Background(), logr.Discard(), instance)
+	assert.NoError(t, err)
+
+	// UNCHANGED already has the desired value and is left alone
+	assert.Equal(t, []string{
+		"PATCH /repos/fr123k/test_repo/actions/variables/CHANGED",
+		"POST /repos/fr123k/test_repo/actions/variables",
+	}, calls)
+	repo := findRepositoryStatus(instance.Status.Repositories, "test_repo")
+	assert.True(t, apimeta.IsStatusConditionTrue(repo.Conditions, secretv1alpha1.ConditionTypeReady))
+	assert.NotNil(t, findVariableStatus(repo.Variables, "Repository", "CHANGED"))
+	assert.NotNil(t, findVariableStatus(repo.Variables, "Repository", "MISSING"))
+	assert.Nil(t, findVariableStatus(repo.Variables, "Repository", "UNCHANGED"))
+}
@@ -33,9 +33,13 @@ import (
 
 	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
 	"github.com/fr123k/github-operator/controllers"
+	"github.com/fr123k/github-operator/pkg/aws"
+	"github.com/fr123k/github-operator/pkg/azure"
 	"github.com/fr123k/github-operator/pkg/config"
 	"github.com/fr123k/github-operator/pkg/gcloud"
 	"github.com/fr123k/github-operator/pkg/github"
+	"github.com/fr123k/github-operator/pkg/source"
+	"github.com/fr123k/github-operator/pkg/vault"
 
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	//+kubebuilder:scaffold:imports
@@ -101,16 +105,74 @@ func main() {
 
 	cfg, _ := config.Configure()
 
+	gh := github.NewClient(cfg)
+	versionKey := []byte(cfg.VersionKey)
+	if len(versionKey) == 0 {
+		versionKey = []byte(cfg.GitHubToken)
+	}
+	sources := source.NewRegistry()
+	sources.Register(secretv1alpha1.SecretSourceGCP, secretv1alpha1.ConditionTypeGCPSecretManagerError, source.GCP{Client: gcloud.NewClient(cfg)})
+	sources.Register(secretv1alpha1.SecretSourceKubernetes, secretv1alpha1.ConditionTypeKubernetesSecretError, source.Kubernetes{Client: mgr.GetClient(), VersionKey: versionKey})
+	sources.Register(secretv1alpha1.SecretSourceGenerated, secretv1alpha1.ConditionTypeGeneratedSecretError, source.Generated{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), VersionKey: versionKey})
+	if cfg.VaultAddress != "" {
+		vc, err := vault.NewClient(cfg)
+		if err != nil {
+			setupLog.Error(err, "unable to create Vault client")
+			os.Exit(1)
+		}
+		sources.Register(secretv1alpha1.SecretSourceVault, secretv1alpha1.ConditionTypeVaultError, source.Vault{Client: vc, VersionKey: versionKey})
+	}
+	if cfg.AWSRegion != "" {
+		ac, err := aws.NewClient(cfg)
+		if err != nil {
+			setupLog.Error(err, "unable to create AWS client")
+			os.Exit(1)
+		}
+		sources.Register(secretv1alpha1.SecretSourceAWSSecretsManager, secretv1alpha1.ConditionTypeAWSError, source.AWSSecretsManager{Client: ac})
+		sources.Register(secretv1alpha1.SecretSourceAWSParameterStore, secretv1alpha1.ConditionTypeAWSError, source.AWSParameterStore{Client: ac})
+	}
+	if cfg.AzureTenantID != "" {
+		azc, err := azure.NewClient(cfg)
+		if err != nil {
+			setupLog.Error(err, "unable to create Azure client")
+			os.Exit(1)
+		}
+		sources.Register(secretv1alpha1.SecretSourceAzureKeyVault, secretv1alpha1.ConditionTypeAzureKeyVaultError, source.AzureKeyVault{Client: azc})
+	}
+	if cfg.SOPSKeysPath != "" {
+		sources.Register(secretv1alpha1.SecretSourceSOPS, secretv1alpha1.ConditionTypeSOPSError, source.SOPS{Client: mgr.GetClient(), KeysPath: cfg.SOPSKeysPath, VersionKey: versionKey})
+	}
+
 	if err = (&controllers.GithubSecretReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Github: github.NewClient(cfg),
-		GCloud: gcloud.NewClient(cfg),
-		Config: cfg,
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Github:  gh,
+		Sources: sources,
+		Config:  cfg,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubSecret")
 		os.Exit(1)
 	}
+	if err = (&controllers.GithubOrgSecretReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Github:  gh,
+		Sources: sources,
+		Config:  cfg,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubOrgSecret")
+		os.Exit(1)
+	}
+	if err = (&controllers.ClusterGithubSecretReconciler{GithubSecretReconciler: controllers.GithubSecretReconciler{
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Github:  gh,
+		Sources: sources,
+		Config:  cfg,
+	}}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterGithubSecret")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
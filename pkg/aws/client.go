@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+// versionID matches the UUIDs of the Secrets Manager secret versions, other versions are staging labels.
+var versionID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// AWSClient reads secrets from AWS Secrets Manager and parameters from the SSM Parameter Store.
+// The credentials are static if they're configured, otherwise the default credential chain is used,
+// which covers IAM roles for service accounts (IRSA).
+type AWSClient struct {
+	ctx    context.Context
+	cfg    config.Config
+	awsCfg awssdk.Config
+}
+
+type Option func(*AWSClient)
+
+func WithContext(ctx context.Context) Option {
+	return func(a *AWSClient) {
+		a.ctx = ctx
+	}
+}
+
+func NewClient(cfg config.Config, opts ...Option) (*AWSClient, error) {
+	ac := &AWSClient{cfg: cfg, ctx: context.Background()}
+
+	for _, opt := range opts {
+		opt(ac)
+	}
+
+	loadOpts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(cfg.AWSRegion),
+	}
+	if cfg.AWSAccessKeyID != "" {
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AWSAccessKeyID, cfg.AWSSecretAccessKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ac.ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+	ac.awsCfg = awsCfg
+	return ac, nil
+}
+
+// SecretVersion is the value of a Secrets Manager secret or a parameter together with the VersionId
+// of the secret or the version number of the parameter.
+type SecretVersion struct {
+	Value   string
+	Version string
+}
+
+// GetSecretValue reads the secret from Secrets Manager. The version is either a version id or a
+// staging label, it defaults to AWSCURRENT. The region defaults to the configured one.
+func (ac *AWSClient) GetSecretValue(region string, name string, version string) (*SecretVersion, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: awssdk.String(name),
+	}
+	switch {
+	case version == "":
+	case versionID.MatchString(version):
+		input.VersionId = awssdk.String(version)
+	default:
+		input.VersionStage = awssdk.String(version)
+	}
+
+	client := secretsmanager.NewFromConfig(ac.awsCfg, func(o *secretsmanager.Options) {
+		ac.regionAndEndpoint(region, &o.Region, &o.BaseEndpoint)
+	})
+	resp, err := client.GetSecretValue(ac.ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	value := awssdk.ToString(resp.SecretString)
+	if resp.SecretString == nil {
+		value = string(resp.SecretBinary)
+	}
+	return &SecretVersion{
+		Value:   value,
+		Version: awssdk.ToString(resp.VersionId),
+	}, nil
+}
+
+// GetParameter reads the parameter from the SSM Parameter Store, SecureString parameters are
+// decrypted. The version is either a version number or a label, it defaults to the latest version.
+func (ac *AWSClient) GetParameter(region string, name string, version string) (*SecretVersion, error) {
+	if version != "" {
+		name = fmt.Sprintf("%s:%s", name, version)
+	}
+
+	client := ssm.NewFromConfig(ac.awsCfg, func(o *ssm.Options) {
+		ac.regionAndEndpoint(region, &o.Region, &o.BaseEndpoint)
+	})
+	resp, err := client.GetParameter(ac.ctx, &ssm.GetParameterInput{
+		Name:           awssdk.String(name),
+		WithDecryption: awssdk.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Parameter == nil {
+		return nil, fmt.Errorf("parameter %s not found", name)
+	}
+
+	return &SecretVersion{
+		Value:   awssdk.ToString(resp.Parameter.Value),
+		Version: strconv.FormatInt(resp.Parameter.Version, 10),
+	}, nil
+}
+
+// regionAndEndpoint overrides the region of the client if the secret sets one and the endpoint
+// if one is configured, for example to use a local fake in tests.
+func (ac *AWSClient) regionAndEndpoint(region string, clientRegion *string, endpoint **string) {
+	if region != "" {
+		*clientRegion = region
+	}
+	if ac.cfg.AWSEndpoint != "" {
+		*endpoint = awssdk.String(ac.cfg.AWSEndpoint)
+	}
+}
@@ -0,0 +1,129 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+// fakeAWS is an in-process stand-in for the JSON APIs of Secrets Manager and the SSM Parameter Store.
+type fakeAWS struct {
+	requests       []map[string]interface{}
+	targets        []string
+	authorizations []string
+}
+
+func (f *fakeAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	target := r.Header.Get("X-Amz-Target")
+	f.requests = append(f.requests, body)
+	f.targets = append(f.targets, target)
+	// the region is part of the credential scope of the signature
+	f.authorizations = append(f.authorizations, r.Header.Get("Authorization"))
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	switch target {
+	case "secretsmanager.GetSecretValue":
+		if body["SecretId"] != "db-credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"Name":          "db-credentials",
+			"SecretString":  `{"username":"admin","password":"secret"}`,
+			"VersionId":     "8f9d3f2a-1b2c-4d5e-8f9a-0b1c2d3e4f5a",
+			"VersionStages": []string{"AWSCURRENT"},
+		})
+	case "AmazonSSM.GetParameter":
+		name := body["Name"].(string)
+		version := 3
+		if strings.HasSuffix(name, ":2") {
+			version = 2
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"Parameter": map[string]interface{}{
+				"Name":    strings.TrimSuffix(name, ":2"),
+				"Type":    "SecureString",
+				"Value":   "parameter-value",
+				"Version": version,
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newTestClient(t *testing.T, fake *fakeAWS) *AWSClient {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	ac, err := NewClient(config.Config{
+		AWSRegion:          "eu-west-1",
+		AWSEndpoint:        server.URL,
+		AWSAccessKeyID:     "access-key",
+		AWSSecretAccessKey: "secret-key",
+	}, WithContext(context.Background()))
+	assert.NoError(t, err)
+	return ac
+}
+
+func TestGetSecretValue(t *testing.T) {
+	fake := &fakeAWS{}
+	ac := newTestClient(t, fake)
+
+	secret, err := ac.GetSecretValue("", "db-credentials", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"username":"admin","password":"secret"}`, secret.Value)
+	assert.Equal(t, "8f9d3f2a-1b2c-4d5e-8f9a-0b1c2d3e4f5a", secret.Version)
+	assert.Equal(t, map[string]interface{}{"SecretId": "db-credentials"}, fake.requests[0])
+	assert.Contains(t, fake.authorizations[0], "/eu-west-1/secretsmanager/")
+}
+
+func TestGetSecretValueVersion(t *testing.T) {
+	fake := &fakeAWS{}
+	ac := newTestClient(t, fake)
+
+	_, err := ac.GetSecretValue("us-east-1", "db-credentials", "AWSPREVIOUS")
+	assert.NoError(t, err)
+	_, err = ac.GetSecretValue("", "db-credentials", "8f9d3f2a-1b2c-4d5e-8f9a-0b1c2d3e4f5a")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "AWSPREVIOUS", fake.requests[0]["VersionStage"])
+	assert.Contains(t, fake.authorizations[0], "/us-east-1/secretsmanager/")
+	assert.Equal(t, "8f9d3f2a-1b2c-4d5e-8f9a-0b1c2d3e4f5a", fake.requests[1]["VersionId"])
+}
+
+func TestGetSecretValueNotFound(t *testing.T) {
+	ac := newTestClient(t, &fakeAWS{})
+
+	_, err := ac.GetSecretValue("", "missing", "")
+
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+}
+
+func TestGetParameter(t *testing.T) {
+	fake := &fakeAWS{}
+	ac := newTestClient(t, fake)
+
+	parameter, err := ac.GetParameter("", "/team/app/token", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "parameter-value", parameter.Value)
+	assert.Equal(t, "3", parameter.Version)
+	assert.Equal(t, map[string]interface{}{"Name": "/team/app/token", "WithDecryption": true}, fake.requests[0])
+	assert.Equal(t, "AmazonSSM.GetParameter", fake.targets[0])
+
+	parameter, err = ac.GetParameter("", "/team/app/token", "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", parameter.Version)
+	assert.Equal(t, "/team/app/token:2", fake.requests[1]["Name"])
+}
@@ -0,0 +1,121 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+// AzureClient reads secrets from Azure Key Vaults. It authenticates with the client secret if one
+// is configured, otherwise with the workload identity of the operator's service account.
+type AzureClient struct {
+	ctx        context.Context
+	cfg        config.Config
+	credential azcore.TokenCredential
+	opts       *azsecrets.ClientOptions
+
+	mu      sync.Mutex
+	clients map[string]*azsecrets.Client
+}
+
+type Option func(*AzureClient)
+
+func WithContext(ctx context.Context) Option {
+	return func(a *AzureClient) {
+		a.ctx = ctx
+	}
+}
+
+func WithCredential(credential azcore.TokenCredential) Option {
+	return func(a *AzureClient) {
+		a.credential = credential
+	}
+}
+
+func WithOptions(opts *azsecrets.ClientOptions) Option {
+	return func(a *AzureClient) {
+		a.opts = opts
+	}
+}
+
+func NewClient(cfg config.Config, opts ...Option) (*AzureClient, error) {
+	ac := &AzureClient{cfg: cfg, ctx: context.Background(), clients: map[string]*azsecrets.Client{}}
+
+	for _, opt := range opts {
+		opt(ac)
+	}
+
+	if ac.credential != nil {
+		return ac, nil
+	}
+
+	var err error
+	if cfg.AzureClientSecret != "" {
+		ac.credential, err = azidentity.NewClientSecretCredential(cfg.AzureTenantID, cfg.AzureClientID, cfg.AzureClientSecret, nil)
+	} else {
+		// the token file is injected by the workload identity webhook
+		ac.credential, err = azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			TenantID: cfg.AzureTenantID,
+			ClientID: cfg.AzureClientID,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ac, nil
+}
+
+// SecretVersion is the value of a Key Vault secret together with its version, the GUID at the end
+// of the secret id.
+type SecretVersion struct {
+	Value   string
+	Version string
+}
+
+// GetSecret reads the secret from the Key Vault with the URL, for example https://my-vault.vault.azure.net.
+// The version defaults to the latest version of the secret.
+func (ac *AzureClient) GetSecret(vaultURL string, name string, version string) (*SecretVersion, error) {
+	if vaultURL == "" {
+		return nil, fmt.Errorf("the Azure Key Vault secret %s requires a vaultURL", name)
+	}
+
+	client, err := ac.client(vaultURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetSecret(ac.ctx, name, version, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Value == nil {
+		return nil, fmt.Errorf("secret %s of Azure Key Vault %s has no value", name, vaultURL)
+	}
+
+	secret := &SecretVersion{Value: *resp.Value}
+	if resp.ID != nil {
+		secret.Version = resp.ID.Version()
+	}
+	return secret, nil
+}
+
+// client returns the client of the Key Vault, the clients are reused for all secrets of a Key Vault.
+func (ac *AzureClient) client(vaultURL string) (*azsecrets.Client, error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if client, ok := ac.clients[vaultURL]; ok {
+		return client, nil
+	}
+	client, err := azsecrets.NewClient(vaultURL, ac.credential, ac.opts)
+	if err != nil {
+		return nil, err
+	}
+	ac.clients[vaultURL] = client
+	return client, nil
+}
@@ -0,0 +1,82 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets/fake"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+// newTestClient returns a client that reads the secrets from the versions, the last one is the latest.
+func newTestClient(t *testing.T, versions map[string][]string) *AzureClient {
+	server := fake.Server{
+		GetSecret: func(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (resp azfake.Responder[azsecrets.GetSecretResponse], errResp azfake.ErrorResponder) {
+			// the fake server's path pattern also matches the slash, so the version can end up in the name
+			if n, v, ok := strings.Cut(strings.TrimSuffix(name, "/"), "/"); ok {
+				name, version = n, v
+			}
+			name = strings.TrimSuffix(name, "/")
+			values, ok := versions[name]
+			if !ok {
+				errResp.SetResponseError(http.StatusNotFound, "SecretNotFound")
+				return
+			}
+			index := len(values) - 1
+			if version != "" {
+				_, _ = fmt.Sscanf(version, "v%d", &index)
+				index--
+			}
+			resp.SetResponse(http.StatusOK, azsecrets.GetSecretResponse{Secret: azsecrets.Secret{
+				ID:    to.Ptr(azsecrets.ID(fmt.Sprintf("https://fake-vault.vault.azure.net/secrets/%s/v%d", name, index+1))),
+				Value: to.Ptr(values[index]),
+			}}, nil)
+			return
+		},
+	}
+
+	ac, err := NewClient(config.Config{},
+		WithContext(context.Background()),
+		WithCredential(&azfake.TokenCredential{}),
+		WithOptions(&azsecrets.ClientOptions{ClientOptions: azcore.ClientOptions{Transport: fake.NewServerTransport(&server)}}),
+	)
+	assert.NoError(t, err)
+	return ac
+}
+
+func TestGetSecret(t *testing.T) {
+	ac := newTestClient(t, map[string][]string{"db-password": {"old", "new"}})
+
+	secret, err := ac.GetSecret("https://fake-vault.vault.azure.net", "db-password", "")
+	assert.NoError(t, err)
+	assert.Equal(t, &SecretVersion{Value: "new", Version: "v2"}, secret)
+
+	secret, err = ac.GetSecret("https://fake-vault.vault.azure.net", "db-password", "v1")
+	assert.NoError(t, err)
+	assert.Equal(t, &SecretVersion{Value: "old", Version: "v1"}, secret)
+}
+
+func TestGetSecretNotFound(t *testing.T) {
+	ac := newTestClient(t, map[string][]string{})
+
+	_, err := ac.GetSecret("https://fake-vault.vault.azure.net", "missing", "")
+
+	assert.ErrorContains(t, err, "SecretNotFound")
+}
+
+func TestGetSecretWithoutVaultURL(t *testing.T) {
+	ac := newTestClient(t, map[string][]string{})
+
+	_, err := ac.GetSecret("", "db-password", "")
+
+	assert.EqualError(t, err, "the Azure Key Vault secret db-password requires a vaultURL")
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 )
@@ -14,6 +15,45 @@ type Config struct {
 	Debug           bool   `default:"false" envconfig:"DEBUG"`
 	Owner           string `default:"fr123k" envconfig:"OWNER"`
 	Project         string `default:"flink-core-shared" envconfig:"PROJECT"`
+	// ResyncPeriod is the interval in which the secrets in Github are compared with the desired ones.
+	ResyncPeriod time.Duration `default:"10m" envconfig:"RESYNC_PERIOD"`
+
+	// VaultAddress enables the Vault secret source.
+	VaultAddress   string `envconfig:"VAULT_ADDR"`
+	VaultNamespace string `envconfig:"VAULT_NAMESPACE"`
+	// VaultAuthMethod is the method the operator uses to login to Vault, kubernetes, approle or token.
+	VaultAuthMethod              string `default:"kubernetes" envconfig:"VAULT_AUTH_METHOD"`
+	VaultToken                   string `envconfig:"VAULT_TOKEN"`
+	VaultRole                    string `envconfig:"VAULT_ROLE"`
+	VaultKubernetesMountPath     string `default:"kubernetes" envconfig:"VAULT_KUBERNETES_MOUNT_PATH"`
+	VaultServiceAccountTokenPath string `default:"/var/run/secrets/kubernetes.io/serviceaccount/token" envconfig:"VAULT_SERVICE_ACCOUNT_TOKEN_PATH"`
+	VaultAppRoleMountPath        string `default:"approle" envconfig:"VAULT_APPROLE_MOUNT_PATH"`
+	VaultAppRoleID               string `envconfig:"VAULT_APPROLE_ROLE_ID"`
+	VaultAppRoleSecretID         string `envconfig:"VAULT_APPROLE_SECRET_ID"`
+
+	// AWSRegion enables the AWS Secrets Manager and Parameter Store secret sources.
+	AWSRegion string `envconfig:"AWS_REGION"`
+	// AWSEndpoint overrides the endpoint of the AWS services, for example to use a local fake.
+	AWSEndpoint string `envconfig:"AWS_ENDPOINT_URL"`
+	// AWSAccessKeyID and AWSSecretAccessKey are static credentials, the default credential
+	// chain (for example IRSA) is used if they're not set.
+	AWSAccessKeyID     string `envconfig:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY"`
+
+	// AzureTenantID enables the Azure Key Vault secret source. The operator authenticates with the
+	// client secret if it's set, otherwise with workload identity.
+	AzureTenantID     string `envconfig:"AZURE_TENANT_ID"`
+	AzureClientID     string `envconfig:"AZURE_CLIENT_ID"`
+	AzureClientSecret string `envconfig:"AZURE_CLIENT_SECRET"`
+
+	// SOPSKeysPath enables the SOPS secret source. It's the directory with the age identities and
+	// armored PGP private keys, usually the mount of a Kubernetes Secret.
+	SOPSKeysPath string `envconfig:"SOPS_KEYS_PATH"`
+
+	// VersionKey keys the versions recorded for the values of the Kubernetes, Generated and SOPS
+	// sources and Vault KV version 1 secrets, which aren't versioned by the source. It defaults to
+	// the Github token, changing it pushes those secrets to Github again.
+	VersionKey string `envconfig:"VERSION_KEY"`
 }
 
 func Configure() (Config, context.Context) {
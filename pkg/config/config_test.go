@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,4 +24,16 @@ func TestConfigure(t *testing.T) {
 	assert.NotNil(t, cfg)
 
 	assert.Equal(t, "secret", cfg.GitHubToken)
+	assert.Equal(t, 10*time.Minute, cfg.ResyncPeriod)
+	assert.Equal(t, "", cfg.VaultAddress)
+	assert.Equal(t, "kubernetes", cfg.VaultAuthMethod)
+}
+
+func TestConfigureResyncPeriod(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	t.Setenv("RESYNC_PERIOD", "90s")
+
+	cfg, _ := Configure()
+
+	assert.Equal(t, 90*time.Second, cfg.ResyncPeriod)
 }
@@ -3,6 +3,8 @@ package gcloud
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
@@ -50,15 +52,74 @@ func NewClient(cfg config.Config, opts ...Option) GCloudClient {
 	return gc
 }
 
+// SecretVersion is the payload of a Secret Manager secret version together with the name of the
+// version, the version number an alias like latest resolved to.
+type SecretVersion struct {
+	Value   string
+	Version string
+}
+
 func (gc GCloudClient) GetSecretValue(key string) (*string, error) {
+	secret, err := gc.GetSecretVersion("", key, "")
+	if err != nil {
+		return nil, err
+	}
+	return &secret.Value, nil
+}
+
+// GetSecretVersion reads the given version of the secret and returns its value and version number.
+// The version is either a version number or an alias, it defaults to latest if it's empty.
+// The project defaults to the one configured for the operator, the key can also be the full
+// projects/<project>/secrets/<secret>[/versions/<version>] resource name of the secret.
+func (gc GCloudClient) GetSecretVersion(project string, key string, version string) (*SecretVersion, error) {
+	name, err := gc.secretVersionName(project, key, version)
+	if err != nil {
+		return nil, err
+	}
 	req := &secretmanagerpb.AccessSecretVersionRequest{
-		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", gc.cfg.Project, key),
+		Name: name,
 	}
 	resp, err := gc.client.AccessSecretVersion(gc.ctx, req)
 	if err != nil {
 		// logger.Errorw("failed to access secret version", "error", err, "request", req)
 		return nil, err
 	}
-	str := string(resp.Payload.Data)
-	return &str, nil
+	// the response name contains the resolved version number instead of the alias
+	return &SecretVersion{
+		Value:   string(resp.Payload.Data),
+		Version: path.Base(resp.Name),
+	}, nil
+}
+
+// secretVersionName returns the resource name of the secret version in the format
+// projects/<project>/secrets/<secret>/versions/<version>.
+func (gc GCloudClient) secretVersionName(project string, key string, version string) (string, error) {
+	if !strings.HasPrefix(key, "projects/") {
+		if project == "" {
+			project = gc.cfg.Project
+		}
+		if version == "" {
+			version = "latest"
+		}
+		return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", project, key, version), nil
+	}
+
+	parts := strings.Split(key, "/")
+	if (len(parts) != 4 && len(parts) != 6) || parts[2] != "secrets" || (len(parts) == 6 && parts[4] != "versions") {
+		return "", fmt.Errorf("invalid secret name %s, expected projects/<project>/secrets/<secret>[/versions/<version>]", key)
+	}
+	if project != "" && project != parts[1] {
+		return "", fmt.Errorf("secret %s conflicts with project %s", key, project)
+	}
+	if len(parts) == 6 {
+		if version != "" && version != parts[5] {
+			return "", fmt.Errorf("secret %s conflicts with version %s", key, version)
+		}
+		return key, nil
+	}
+
+	if version == "" {
+		version = "latest"
+	}
+	return fmt.Sprintf("%s/versions/%s", key, version), nil
 }
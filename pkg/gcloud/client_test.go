@@ -0,0 +1,184 @@
+package gcloud
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+// fakeSecretManagerServer resolves the latest alias to the last version of the secret
+// and version numbers to the value at that position.
+type fakeSecretManagerServer struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+
+	requests []string
+	versions map[string][]string
+}
+
+func (f *fakeSecretManagerServer) AccessSecretVersion(_ context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	f.requests = append(f.requests, req.Name)
+
+	secret, version, _ := strings.Cut(req.Name, "/versions/")
+	versions := f.versions[secret]
+	number := len(versions)
+	if version != "latest" {
+		var err error
+		number, err = strconv.Atoi(version)
+		if err != nil || number < 1 || number > len(versions) {
+			return nil, status.Errorf(codes.NotFound, "secret version %s not found", req.Name)
+		}
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name: secret + "/versions/" + strconv.Itoa(number),
+		Payload: &secretmanagerpb.SecretPayload{
+			Data: []byte(versions[number-1]),
+		},
+	}, nil
+}
+
+func newFakeClient(t *testing.T, server *fakeSecretManagerServer) GCloudClient {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gsrv := grpc.NewServer()
+	secretmanagerpb.RegisterSecretManagerServiceServer(gsrv, server)
+	go func() {
+		_ = gsrv.Serve(l)
+	}()
+	t.Cleanup(gsrv.Stop)
+
+	return NewClient(config.Config{Project: "fr123k"},
+		WithContext(context.Background()),
+		WithOptions(option.WithEndpoint(l.Addr().String()),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials()))),
+	)
+}
+
+func TestGetSecretVersion(t *testing.T) {
+	server := &fakeSecretManagerServer{versions: map[string][]string{
+		"projects/fr123k/secrets/token": {"old", "new"},
+	}}
+	gc := newFakeClient(t, server)
+
+	secret, err := gc.GetSecretVersion("", "token", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "new", secret.Value)
+	assert.Equal(t, "2", secret.Version)
+	assert.Equal(t, []string{"projects/fr123k/secrets/token/versions/latest"}, server.requests)
+}
+
+func TestGetSecretVersionPinned(t *testing.T) {
+	server := &fakeSecretManagerServer{versions: map[string][]string{
+		"projects/fr123k/secrets/token": {"old", "new"},
+	}}
+	gc := newFakeClient(t, server)
+
+	secret, err := gc.GetSecretVersion("", "token", "1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "old", secret.Value)
+	assert.Equal(t, "1", secret.Version)
+	assert.Equal(t, []string{"projects/fr123k/secrets/token/versions/1"}, server.requests)
+}
+
+func TestGetSecretVersionNotFound(t *testing.T) {
+	server := &fakeSecretManagerServer{versions: map[string][]string{
+		"projects/fr123k/secrets/token": {"value"},
+	}}
+	gc := newFakeClient(t, server)
+
+	secret, err := gc.GetSecretVersion("", "token", "3")
+
+	assert.Error(t, err)
+	assert.Nil(t, secret)
+}
+
+func TestGetSecretValue(t *testing.T) {
+	server := &fakeSecretManagerServer{versions: map[string][]string{
+		"projects/fr123k/secrets/token": {"value"},
+	}}
+	gc := newFakeClient(t, server)
+
+	value, err := gc.GetSecretValue("token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "value", *value)
+}
+
+func TestGetSecretVersionProject(t *testing.T) {
+	server := &fakeSecretManagerServer{versions: map[string][]string{
+		"projects/team/secrets/token": {"value"},
+	}}
+	gc := newFakeClient(t, server)
+
+	secret, err := gc.GetSecretVersion("team", "token", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "value", secret.Value)
+	assert.Equal(t, []string{"projects/team/secrets/token/versions/latest"}, server.requests)
+}
+
+func TestGetSecretVersionResourceName(t *testing.T) {
+	server := &fakeSecretManagerServer{versions: map[string][]string{
+		"projects/team/secrets/token": {"old", "new"},
+	}}
+	gc := newFakeClient(t, server)
+
+	secret, err := gc.GetSecretVersion("", "projects/team/secrets/token", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", secret.Value)
+
+	secret, err = gc.GetSecretVersion("", "projects/team/secrets/token/versions/1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", secret.Value)
+
+	assert.Equal(t, []string{
+		"projects/team/secrets/token/versions/latest",
+		"projects/team/secrets/token/versions/1",
+	}, server.requests)
+}
+
+func TestSecretVersionName(t *testing.T) {
+	gc := GCloudClient{cfg: config.Config{Project: "fr123k"}}
+
+	tests := []struct {
+		project string
+		key     string
+		version string
+		name    string
+		err     bool
+	}{
+		{key: "token", name: "projects/fr123k/secrets/token/versions/latest"},
+		{key: "token", version: "3", name: "projects/fr123k/secrets/token/versions/3"},
+		{project: "team", key: "token", version: "prod", name: "projects/team/secrets/token/versions/prod"},
+		{key: "projects/team/secrets/token", version: "2", name: "projects/team/secrets/token/versions/2"},
+		{project: "team", key: "projects/team/secrets/token/versions/2", version: "2", name: "projects/team/secrets/token/versions/2"},
+		{project: "other", key: "projects/team/secrets/token", err: true},
+		{key: "projects/team/secrets/token/versions/2", version: "3", err: true},
+		{key: "projects/team/token", err: true},
+	}
+	for _, test := range tests {
+		name, err := gc.secretVersionName(test.project, test.key, test.version)
+		if test.err {
+			assert.Error(t, err, test.key)
+			continue
+		}
+		assert.NoError(t, err, test.key)
+		assert.Equal(t, test.name, name)
+	}
+}
@@ -2,7 +2,10 @@ package github
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/google/go-github/v54/github"
 	"golang.org/x/oauth2"
@@ -13,11 +16,15 @@ import (
 type Secret = github.Secret
 type Secrets = github.Secrets
 type PublicKey = github.PublicKey
+type Variable = github.ActionsVariable
+type Variables = github.ActionsVariables
 
 type GithubClient struct {
 	client *github.Client
 	ctx    context.Context
 	cfg    config.Config
+	// repositoryIDs caches the ids of the repositories by owner/repository, shared by the copies of the client
+	repositoryIDs *sync.Map
 }
 
 type Option func(*GithubClient)
@@ -34,8 +41,15 @@ func WithClient(cli *http.Client) Option {
 	}
 }
 
+// IsNotFound tells whether the error is a 404 response of the Github API, for example
+// for a secret that has already been removed.
+func IsNotFound(err error) bool {
+	var respErr *github.ErrorResponse
+	return errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.StatusCode == http.StatusNotFound
+}
+
 func NewClient(cfg config.Config, opts ...Option) GithubClient {
-	gc := GithubClient{cfg: cfg}
+	gc := GithubClient{cfg: cfg, repositoryIDs: &sync.Map{}}
 
 	for _, opt := range opts {
 		opt(&gc)
@@ -54,8 +68,8 @@ func NewClient(cfg config.Config, opts ...Option) GithubClient {
 	return gc
 }
 
-func (gh GithubClient) RemoveDependaBotSecrets(repository string, secretName string) error {
-	_, err := gh.client.Dependabot.DeleteRepoSecret(gh.ctx, gh.cfg.Owner, repository, secretName)
+func (gh GithubClient) RemoveDependaBotSecrets(owner, repository string, secretName string) error {
+	_, err := gh.client.Dependabot.DeleteRepoSecret(gh.ctx, owner, repository, secretName)
 	if err != nil {
 		return err
 	}
@@ -63,24 +77,434 @@ func (gh GithubClient) RemoveDependaBotSecrets(repository string, secretName str
 	return nil
 }
 
-func (gh GithubClient) ListDependaBotSecrets(repository string) (*github.Secrets, error) {
-	opts := &github.ListOptions{Page: 0, PerPage: 100}
-	secrets, _, err := gh.client.Dependabot.ListRepoSecrets(gh.ctx, gh.cfg.Owner, repository, opts)
+func (gh GithubClient) ListDependaBotSecrets(owner, repository string) (*github.Secrets, error) {
+	return listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Dependabot.ListRepoSecrets(gh.ctx, owner, repository, opts)
+	})
+}
+
+func (gh GithubClient) AddDependaBotSecrets(owner, repository string, name string, value string) (*github.DependabotEncryptedSecret, error) {
+
+	pk, _, err := gh.client.Dependabot.GetRepoPublicKey(gh.ctx, owner, repository)
 	if err != nil {
 		return nil, err
 	}
 
-	return secrets, nil
+	secret := &github.DependabotEncryptedSecret{
+		Name:  name,
+		KeyID: *pk.KeyID,
+	}
+
+	secret.EncryptedValue, err = Encrypt(*pk.Key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gh.client.Dependabot.CreateOrUpdateRepoSecret(gh.ctx, owner, repository, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
 }
 
-func (gh GithubClient) AddDependaBotSecrets(owner, repository string, name string, value string) (*github.DependabotEncryptedSecret, error) {
+func (gh GithubClient) RemoveActionsSecrets(owner, repository string, secretName string) error {
+	_, err := gh.client.Actions.DeleteRepoSecret(gh.ctx, owner, repository, secretName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListActionsSecrets(owner, repository string) (*github.Secrets, error) {
+	return listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Actions.ListRepoSecrets(gh.ctx, owner, repository, opts)
+	})
+}
+
+func (gh GithubClient) AddActionsSecrets(owner, repository string, name string, value string) (*github.EncryptedSecret, error) {
+
+	pk, _, err := gh.client.Actions.GetRepoPublicKey(gh.ctx, owner, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:  name,
+		KeyID: *pk.KeyID,
+	}
+
+	secret.EncryptedValue, err = Encrypt(*pk.Key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gh.client.Actions.CreateOrUpdateRepoSecret(gh.ctx, owner, repository, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func (gh GithubClient) RemoveCodespacesSecrets(owner, repository string, secretName string) error {
+	_, err := gh.client.Codespaces.DeleteRepoSecret(gh.ctx, owner, repository, secretName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListCodespacesSecrets(owner, repository string) (*github.Secrets, error) {
+	return listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Codespaces.ListRepoSecrets(gh.ctx, owner, repository, opts)
+	})
+}
+
+func (gh GithubClient) AddCodespacesSecrets(owner, repository string, name string, value string) (*github.EncryptedSecret, error) {
+
+	pk, _, err := gh.client.Codespaces.GetRepoPublicKey(gh.ctx, owner, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:  name,
+		KeyID: *pk.KeyID,
+	}
+
+	secret.EncryptedValue, err = Encrypt(*pk.Key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gh.client.Codespaces.CreateOrUpdateRepoSecret(gh.ctx, owner, repository, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// RepositoryID resolves the numeric id of a repository which some of the Github APIs expect instead of its name.
+// The id is cached, so the environment APIs don't look it up for every secret and variable.
+func (gh GithubClient) RepositoryID(owner, repository string) (int64, error) {
+	key := owner + "/" + repository
+	if gh.repositoryIDs != nil {
+		if id, ok := gh.repositoryIDs.Load(key); ok {
+			return id.(int64), nil
+		}
+	}
+
+	repo, _, err := gh.client.Repositories.Get(gh.ctx, owner, repository)
+	if err != nil {
+		return 0, err
+	}
 
-	pk, _, err := gh.client.Dependabot.GetRepoPublicKey(gh.ctx, gh.cfg.Owner, repository)
+	if gh.repositoryIDs != nil {
+		gh.repositoryIDs.Store(key, repo.GetID())
+	}
+	return repo.GetID(), nil
+}
+
+// forgetRepositoryID removes the cached id of the repository if the error is a 404 response,
+// the repository may have been recreated with another id.
+func (gh GithubClient) forgetRepositoryID(owner, repository string, err error) {
+	if gh.repositoryIDs != nil && IsNotFound(err) {
+		gh.repositoryIDs.Delete(owner + "/" + repository)
+	}
+}
+
+// ListRepositories returns all repositories of the owner, which is either an organization or a user.
+func (gh GithubClient) ListRepositories(owner string) ([]*github.Repository, error) {
+	var repositories []*github.Repository
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := gh.client.Repositories.ListByOrg(gh.ctx, owner, opts)
+		if IsNotFound(err) {
+			return gh.listUserRepositories(owner)
+		}
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, page...)
+		if resp.NextPage == 0 {
+			return repositories, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listUserRepositories returns the repositories of the user. The repositories of other users are
+// listed by /users/{user}/repos which only returns the public ones, so the private repositories of
+// the authenticated user are listed by /user/repos.
+func (gh GithubClient) listUserRepositories(user string) ([]*github.Repository, error) {
+	var repositories []*github.Repository
+	opts := &github.RepositoryListOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	// tokens of Github Apps can't read the authenticated user, they fall back to the public repositories
+	if authenticated, _, err := gh.client.Users.Get(gh.ctx, ""); err == nil && strings.EqualFold(authenticated.GetLogin(), user) {
+		user = ""
+		opts = &github.RepositoryListOptions{Affiliation: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	}
+	for {
+		page, resp, err := gh.client.Repositories.List(gh.ctx, user, opts)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, page...)
+		if resp.NextPage == 0 {
+			return repositories, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (gh GithubClient) RemoveOrgActionsSecrets(org string, secretName string) error {
+	_, err := gh.client.Actions.DeleteOrgSecret(gh.ctx, org, secretName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListOrgActionsSecrets(org string) (*github.Secrets, error) {
+	return listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Actions.ListOrgSecrets(gh.ctx, org, opts)
+	})
+}
+
+func (gh GithubClient) AddOrgActionsSecrets(org string, name string, value string, visibility string, selectedRepositoryIDs []int64) (*github.EncryptedSecret, error) {
+
+	pk, _, err := gh.client.Actions.GetOrgPublicKey(gh.ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:                  name,
+		KeyID:                 *pk.KeyID,
+		Visibility:            visibility,
+		SelectedRepositoryIDs: selectedRepositoryIDs,
+	}
+
+	secret.EncryptedValue, err = Encrypt(*pk.Key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gh.client.Actions.CreateOrUpdateOrgSecret(gh.ctx, org, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func (gh GithubClient) SetOrgActionsSecretRepositories(org string, name string, selectedRepositoryIDs []int64) error {
+	_, err := gh.client.Actions.SetSelectedReposForOrgSecret(gh.ctx, org, name, selectedRepositoryIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListOrgActionsSecretRepositories returns the ids of the repositories selected for the secret.
+func (gh GithubClient) ListOrgActionsSecretRepositories(org string, name string) ([]int64, error) {
+	return listSelectedRepositoryIDs(func(opts *github.ListOptions) (*github.SelectedReposList, *github.Response, error) {
+		return gh.client.Actions.ListSelectedReposForOrgSecret(gh.ctx, org, name, opts)
+	})
+}
+
+func (gh GithubClient) RemoveOrgDependaBotSecrets(org string, secretName string) error {
+	_, err := gh.client.Dependabot.DeleteOrgSecret(gh.ctx, org, secretName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListOrgDependaBotSecrets(org string) (*github.Secrets, error) {
+	return listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Dependabot.ListOrgSecrets(gh.ctx, org, opts)
+	})
+}
+
+func (gh GithubClient) AddOrgDependaBotSecrets(org string, name string, value string, visibility string, selectedRepositoryIDs []int64) (*github.DependabotEncryptedSecret, error) {
+
+	pk, _, err := gh.client.Dependabot.GetOrgPublicKey(gh.ctx, org)
 	if err != nil {
 		return nil, err
 	}
 
 	secret := &github.DependabotEncryptedSecret{
+		Name:                  name,
+		KeyID:                 *pk.KeyID,
+		Visibility:            visibility,
+		SelectedRepositoryIDs: selectedRepositoryIDs,
+	}
+
+	secret.EncryptedValue, err = Encrypt(*pk.Key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gh.client.Dependabot.CreateOrUpdateOrgSecret(gh.ctx, org, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func (gh GithubClient) SetOrgDependaBotSecretRepositories(org string, name string, selectedRepositoryIDs []int64) error {
+	_, err := gh.client.Dependabot.SetSelectedReposForOrgSecret(gh.ctx, org, name, selectedRepositoryIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListOrgDependaBotSecretRepositories returns the ids of the repositories selected for the secret.
+func (gh GithubClient) ListOrgDependaBotSecretRepositories(org string, name string) ([]int64, error) {
+	return listSelectedRepositoryIDs(func(opts *github.ListOptions) (*github.SelectedReposList, *github.Response, error) {
+		return gh.client.Dependabot.ListSelectedReposForOrgSecret(gh.ctx, org, name, opts)
+	})
+}
+
+func (gh GithubClient) RemoveOrgCodespacesSecrets(org string, secretName string) error {
+	_, err := gh.client.Codespaces.DeleteOrgSecret(gh.ctx, org, secretName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListOrgCodespacesSecrets(org string) (*github.Secrets, error) {
+	return listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Codespaces.ListOrgSecrets(gh.ctx, org, opts)
+	})
+}
+
+func (gh GithubClient) AddOrgCodespacesSecrets(org string, name string, value string, visibility string, selectedRepositoryIDs []int64) (*github.EncryptedSecret, error) {
+
+	pk, _, err := gh.client.Codespaces.GetOrgPublicKey(gh.ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &github.EncryptedSecret{
+		Name:                  name,
+		KeyID:                 *pk.KeyID,
+		Visibility:            visibility,
+		SelectedRepositoryIDs: selectedRepositoryIDs,
+	}
+
+	secret.EncryptedValue, err = Encrypt(*pk.Key, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gh.client.Codespaces.CreateOrUpdateOrgSecret(gh.ctx, org, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func (gh GithubClient) SetOrgCodespacesSecretRepositories(org string, name string, selectedRepositoryIDs []int64) error {
+	_, err := gh.client.Codespaces.SetSelectedReposForOrgSecret(gh.ctx, org, name, selectedRepositoryIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListOrgCodespacesSecretRepositories returns the ids of the repositories selected for the secret.
+func (gh GithubClient) ListOrgCodespacesSecretRepositories(org string, name string) ([]int64, error) {
+	return listSelectedRepositoryIDs(func(opts *github.ListOptions) (*github.SelectedReposList, *github.Response, error) {
+		return gh.client.Codespaces.ListSelectedReposForOrgSecret(gh.ctx, org, name, opts)
+	})
+}
+
+// EnsureEnvironment creates the deployment environment of the repository if it doesn't exist yet.
+// Existing environments are left untouched to keep their protection rules.
+func (gh GithubClient) EnsureEnvironment(owner, repository string, environment string) error {
+	_, resp, err := gh.client.Repositories.GetEnvironment(gh.ctx, owner, repository, environment)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	_, _, err = gh.client.Repositories.CreateUpdateEnvironment(gh.ctx, owner, repository, environment, &github.CreateUpdateEnvironment{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) RemoveEnvironmentSecrets(owner, repository string, environment string, secretName string) error {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return err
+	}
+
+	_, err = gh.client.Actions.DeleteEnvSecret(gh.ctx, int(id), environment, secretName)
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListEnvironmentSecrets(owner, repository string, environment string) (*github.Secrets, error) {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := listSecrets(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return gh.client.Actions.ListEnvSecrets(gh.ctx, int(id), environment, opts)
+	})
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+func (gh GithubClient) AddEnvironmentSecrets(owner, repository string, environment string, name string, value string) (*github.EncryptedSecret, error) {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, _, err := gh.client.Actions.GetEnvPublicKey(gh.ctx, int(id), environment)
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return nil, err
+	}
+
+	secret := &github.EncryptedSecret{
 		Name:  name,
 		KeyID: *pk.KeyID,
 	}
@@ -91,16 +515,226 @@ func (gh GithubClient) AddDependaBotSecrets(owner, repository string, name strin
 		return nil, err
 	}
 
-	_, err = gh.client.Dependabot.CreateOrUpdateRepoSecret(gh.ctx, owner, repository, secret)
+	_, err = gh.client.Actions.CreateOrUpdateEnvSecret(gh.ctx, int(id), environment, secret)
 	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
 		return nil, err
 	}
 
 	return secret, nil
 }
 
-// gh.client.Actions.CreateOrUpdateOrgSecret()
+func (gh GithubClient) RemoveEnvironmentVariable(owner, repository string, environment string, name string) error {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return err
+	}
+
+	_, err = gh.client.Actions.DeleteEnvVariable(gh.ctx, int(id), environment, name)
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return err
+	}
 
-// gh.client.Actions.ListOrgSecrets()
+	return nil
+}
+
+func (gh GithubClient) ListEnvironmentVariables(owner, repository string, environment string) (*github.ActionsVariables, error) {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := listVariables(func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+		return gh.client.Actions.ListEnvVariables(gh.ctx, int(id), environment, opts)
+	})
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return nil, err
+	}
+
+	return variables, nil
+}
 
-// gh.client.Actions.GetOrgPublicKey()
+func (gh GithubClient) AddEnvironmentVariable(owner, repository string, environment string, name string, value string) error {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return err
+	}
+
+	_, err = gh.client.Actions.CreateEnvVariable(gh.ctx, int(id), environment, &github.ActionsVariable{Name: name, Value: value})
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) UpdateEnvironmentVariable(owner, repository string, environment string, name string, value string) error {
+	id, err := gh.RepositoryID(owner, repository)
+	if err != nil {
+		return err
+	}
+
+	_, err = gh.client.Actions.UpdateEnvVariable(gh.ctx, int(id), environment, &github.ActionsVariable{Name: name, Value: value})
+	if err != nil {
+		gh.forgetRepositoryID(owner, repository, err)
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) RemoveRepoVariable(owner, repository string, name string) error {
+	_, err := gh.client.Actions.DeleteRepoVariable(gh.ctx, owner, repository, name)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListRepoVariables(owner, repository string) (*github.ActionsVariables, error) {
+	return listVariables(func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+		return gh.client.Actions.ListRepoVariables(gh.ctx, owner, repository, opts)
+	})
+}
+
+func (gh GithubClient) AddRepoVariable(owner, repository string, name string, value string) error {
+	_, err := gh.client.Actions.CreateRepoVariable(gh.ctx, owner, repository, &github.ActionsVariable{Name: name, Value: value})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) UpdateRepoVariable(owner, repository string, name string, value string) error {
+	_, err := gh.client.Actions.UpdateRepoVariable(gh.ctx, owner, repository, &github.ActionsVariable{Name: name, Value: value})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) RemoveOrgVariable(org string, name string) error {
+	_, err := gh.client.Actions.DeleteOrgVariable(gh.ctx, org, name)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) ListOrgVariables(org string) (*github.ActionsVariables, error) {
+	return listVariables(func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+		return gh.client.Actions.ListOrgVariables(gh.ctx, org, opts)
+	})
+}
+
+func (gh GithubClient) AddOrgVariable(org string, name string, value string, visibility string, selectedRepositoryIDs []int64) error {
+	_, err := gh.client.Actions.CreateOrgVariable(gh.ctx, org, orgVariable(name, value, visibility, selectedRepositoryIDs))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) UpdateOrgVariable(org string, name string, value string, visibility string, selectedRepositoryIDs []int64) error {
+	_, err := gh.client.Actions.UpdateOrgVariable(gh.ctx, org, orgVariable(name, value, visibility, selectedRepositoryIDs))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gh GithubClient) SetOrgVariableRepositories(org string, name string, selectedRepositoryIDs []int64) error {
+	_, err := gh.client.Actions.SetSelectedReposForOrgVariable(gh.ctx, org, name, selectedRepositoryIDs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListOrgVariableRepositories returns the ids of the repositories selected for the variable.
+func (gh GithubClient) ListOrgVariableRepositories(org string, name string) ([]int64, error) {
+	return listSelectedRepositoryIDs(func(opts *github.ListOptions) (*github.SelectedReposList, *github.Response, error) {
+		return gh.client.Actions.ListSelectedReposForOrgVariable(gh.ctx, org, name, opts)
+	})
+}
+
+func orgVariable(name string, value string, visibility string, selectedRepositoryIDs []int64) *github.ActionsVariable {
+	variable := &github.ActionsVariable{
+		Name:       name,
+		Value:      value,
+		Visibility: &visibility,
+	}
+	if visibility == "selected" {
+		ids := github.SelectedRepoIDs(selectedRepositoryIDs)
+		variable.SelectedRepositoryIDs = &ids
+	}
+	return variable
+}
+
+// listSecrets collects all pages of a secrets list call, so secrets beyond the first page
+// aren't taken for missing ones.
+func listSecrets(list func(opts *github.ListOptions) (*github.Secrets, *github.Response, error)) (*github.Secrets, error) {
+	secrets := &github.Secrets{}
+	opts := &github.ListOptions{Page: 0, PerPage: 100}
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		secrets.TotalCount = page.TotalCount
+		secrets.Secrets = append(secrets.Secrets, page.Secrets...)
+		if resp.NextPage == 0 {
+			return secrets, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listVariables collects all pages of a variables list call, the variables API
+// returns at most 30 variables per page.
+func listVariables(list func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error)) (*github.ActionsVariables, error) {
+	variables := &github.ActionsVariables{}
+	opts := &github.ListOptions{Page: 0, PerPage: 30}
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		variables.TotalCount = page.TotalCount
+		variables.Variables = append(variables.Variables, page.Variables...)
+		if resp.NextPage == 0 {
+			return variables, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listSelectedRepositoryIDs collects the repository ids of all pages of a selected repositories
+// list call of an organization secret or variable.
+func listSelectedRepositoryIDs(list func(opts *github.ListOptions) (*github.SelectedReposList, *github.Response, error)) ([]int64, error) {
+	var ids []int64
+	opts := &github.ListOptions{Page: 0, PerPage: 100}
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, repository := range page.Repositories {
+			ids = append(ids, repository.GetID())
+		}
+		if resp.NextPage == 0 {
+			return ids, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -3,6 +3,7 @@ package github
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -84,7 +85,7 @@ func TestListDependaBotSecrets(t *testing.T) {
 		),
 	)
 	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
-	secret, err := client.ListDependaBotSecrets("test_repo")
+	secret, err := client.ListDependaBotSecrets("fr123k", "test_repo")
 
 	assert.NoError(t, err)
 
@@ -127,7 +128,7 @@ func TestAddDependaBotSecretsError(t *testing.T) {
 					http.HandlerFunc(DependaBotSecrets(t)),
 				),
 			),
-			expectedError: "repos/fr123k/test_repo/dependabot/secrets/public-key: 405  []",
+			expectedError: "repos/test_owner/test_repo/dependabot/secrets/public-key: 405  []",
 		},
 		{
 			name:             "to short public key",
@@ -180,3 +181,614 @@ func ErrorStatus(t *testing.T, status int) func(w http.ResponseWriter, r *http.R
 		)
 	}
 }
+
+func TestListActionsSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsSecretsByOwnerByRepo,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.ListActionsSecrets("fr123k", "test_repo")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(secret.Secrets))
+	assert.Equal(t, "Secret 1", secret.Secrets[0].Name)
+	assert.Equal(t, "Secret 2", secret.Secrets[1].Name)
+}
+
+func TestListActionsSecretsPages(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchPages(
+			mock.GetReposActionsSecretsByOwnerByRepo,
+			github.Secrets{TotalCount: 3, Secrets: []*github.Secret{{Name: "Secret 1"}, {Name: "Secret 2"}}},
+			github.Secrets{TotalCount: 3, Secrets: []*github.Secret{{Name: "Secret 3"}}},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.ListActionsSecrets("fr123k", "test_repo")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, secret.TotalCount)
+	assert.Equal(t, 3, len(secret.Secrets))
+	assert.Equal(t, "Secret 3", secret.Secrets[2].Name)
+}
+
+func TestAddActionsSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PutReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsSecretsPublicKeyByOwnerByRepo,
+			http.HandlerFunc(DependaBotPublicKey(t, "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.AddActionsSecrets("test_owner", "test_repo", "test_secret", "test_value")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "test_secret", secret.Name)
+	assert.Equal(t, "test_key_id", secret.KeyID)
+	assert.True(t, len(secret.EncryptedValue) > 0)
+}
+
+func TestAddActionsSecretsError(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PutReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	_, err := client.AddActionsSecrets("test_owner", "test_repo", "test_secret", "test_value")
+
+	assert.ErrorContains(t, err, "repos/test_owner/test_repo/actions/secrets/public-key: 405  []")
+}
+
+func TestRemoveActionsSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			nil,
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.RemoveActionsSecrets("fr123k", "test_repo", "test_secret")
+
+	assert.NoError(t, err)
+}
+
+func Repository(t *testing.T, id int64) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write(mock.MustMarshal(github.Repository{
+			ID: &id,
+		}))
+		assert.NoError(t, err)
+	}
+}
+
+func TestRepositoryID(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(Repository(t, 42)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	id, err := client.RepositoryID("fr123k", "test_repo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+}
+
+func TestListRepositories(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchPages(
+			mock.GetOrgsReposByOrg,
+			[]github.Repository{{Name: github.String("api")}, {Name: github.String("web")}},
+			[]github.Repository{{Name: github.String("worker")}},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	repositories, err := client.ListRepositories("fr123k")
+
+	assert.NoError(t, err)
+	assert.Len(t, repositories, 3)
+	assert.Equal(t, "worker", repositories[2].GetName())
+}
+
+func TestListRepositoriesOfUser(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsReposByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mock.WriteError(w, http.StatusNotFound, "Not Found")
+			}),
+		),
+		mock.WithRequestMatch(
+			mock.GetUsersReposByUsername,
+			[]github.Repository{{Name: github.String("dotfiles")}},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	repositories, err := client.ListRepositories("fr123k")
+
+	assert.NoError(t, err)
+	assert.Len(t, repositories, 1)
+	assert.Equal(t, "dotfiles", repositories[0].GetName())
+}
+
+func TestListRepositoriesOfAuthenticatedUser(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsReposByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mock.WriteError(w, http.StatusNotFound, "Not Found")
+			}),
+		),
+		mock.WithRequestMatch(mock.GetUser, github.User{Login: github.String("fr123k")}),
+		mock.WithRequestMatchHandler(
+			mock.GetUserRepos,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "owner", r.URL.Query().Get("affiliation"))
+				_, _ = w.Write(mock.MustMarshal([]github.Repository{
+					{Name: github.String("dotfiles"), Private: github.Bool(false)},
+					{Name: github.String("infrastructure"), Private: github.Bool(true)},
+				}))
+			}),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	repositories, err := client.ListRepositories("fr123k")
+
+	assert.NoError(t, err)
+	assert.Len(t, repositories, 2)
+	assert.True(t, repositories[1].GetPrivate())
+}
+
+func TestListOrgActionsSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsActionsSecretsByOrg,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.ListOrgActionsSecrets("test_org")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(secret.Secrets))
+	assert.Equal(t, "Secret 1", secret.Secrets[0].Name)
+}
+
+func TestAddOrgActionsSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PutOrgsActionsSecretsByOrgBySecretName,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsActionsSecretsPublicKeyByOrg,
+			http.HandlerFunc(DependaBotPublicKey(t, "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.AddOrgActionsSecrets("test_org", "test_secret", "test_value", "selected", []int64{42})
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "test_secret", secret.Name)
+	assert.Equal(t, "selected", secret.Visibility)
+	assert.Equal(t, github.SelectedRepoIDs{42}, secret.SelectedRepositoryIDs)
+	assert.True(t, len(secret.EncryptedValue) > 0)
+}
+
+func TestAddOrgDependaBotSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PutOrgsDependabotSecretsByOrgBySecretName,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsDependabotSecretsPublicKeyByOrg,
+			http.HandlerFunc(DependaBotPublicKey(t, "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.AddOrgDependaBotSecrets("test_org", "test_secret", "test_value", "all", nil)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "test_secret", secret.Name)
+	assert.Equal(t, "all", secret.Visibility)
+	assert.True(t, len(secret.EncryptedValue) > 0)
+}
+
+func TestAddOrgDependaBotSecretsError(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsDependabotSecretsPublicKeyByOrg,
+			http.HandlerFunc(ErrorStatus(t, http.StatusForbidden)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	_, err := client.AddOrgDependaBotSecrets("test_org", "test_secret", "test_value", "all", nil)
+
+	assert.ErrorContains(t, err, "orgs/test_org/dependabot/secrets/public-key: 403 github went belly up or something")
+}
+
+func TestSetOrgDependaBotSecretRepositories(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.PutOrgsDependabotSecretsRepositoriesByOrgBySecretName,
+			nil,
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.SetOrgDependaBotSecretRepositories("test_org", "test_secret", []int64{1, 2})
+
+	assert.NoError(t, err)
+}
+
+func TestListOrgActionsSecretRepositories(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchPages(
+			mock.GetOrgsActionsSecretsRepositoriesByOrgBySecretName,
+			github.SelectedReposList{TotalCount: github.Int(3), Repositories: []*github.Repository{{ID: github.Int64(1)}, {ID: github.Int64(2)}}},
+			github.SelectedReposList{TotalCount: github.Int(3), Repositories: []*github.Repository{{ID: github.Int64(3)}}},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	ids, err := client.ListOrgActionsSecretRepositories("test_org", "test_secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+}
+
+var (
+	getEnvironmentSecretsByRepositoryID = mock.EndpointPattern{
+		Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets",
+		Method:  "GET",
+	}
+	getEnvironmentPublicKeyByRepositoryID = mock.EndpointPattern{
+		Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets/public-key",
+		Method:  "GET",
+	}
+	putEnvironmentSecretByRepositoryID = mock.EndpointPattern{
+		Pattern: "/repositories/{repository_id}/environments/{environment_name}/secrets/{secret_name}",
+		Method:  "PUT",
+	}
+	getEnvironmentVariablesByRepositoryID = mock.EndpointPattern{
+		Pattern: "/repositories/{repository_id}/environments/{environment_name}/variables",
+		Method:  "GET",
+	}
+	postEnvironmentVariablesByRepositoryID = mock.EndpointPattern{
+		Pattern: "/repositories/{repository_id}/environments/{environment_name}/variables",
+		Method:  "POST",
+	}
+)
+
+func TestEnsureEnvironmentExists(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposEnvironmentsByOwnerByRepoByEnvironmentName,
+			github.Environment{Name: github.String("production")},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.EnsureEnvironment("fr123k", "test_repo", "production")
+
+	assert.NoError(t, err)
+}
+
+func TestEnsureEnvironmentCreate(t *testing.T) {
+	created := false
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposEnvironmentsByOwnerByRepoByEnvironmentName,
+			http.HandlerFunc(ErrorStatus(t, http.StatusNotFound)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.PutReposEnvironmentsByOwnerByRepoByEnvironmentName,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				created = true
+				_, err := w.Write(mock.MustMarshal(github.Environment{Name: github.String("production")}))
+				assert.NoError(t, err)
+			}),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.EnsureEnvironment("fr123k", "test_repo", "production")
+
+	assert.NoError(t, err)
+	assert.True(t, created)
+}
+
+func TestEnsureEnvironmentError(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposEnvironmentsByOwnerByRepoByEnvironmentName,
+			http.HandlerFunc(ErrorStatus(t, http.StatusInternalServerError)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.EnsureEnvironment("fr123k", "test_repo", "production")
+
+	assert.ErrorContains(t, err, "500 github went belly up or something")
+}
+
+func TestListEnvironmentSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(Repository(t, 42)),
+		),
+		mock.WithRequestMatchHandler(
+			getEnvironmentSecretsByRepositoryID,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.ListEnvironmentSecrets("fr123k", "test_repo", "production")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(secret.Secrets))
+	assert.Equal(t, "Secret 1", secret.Secrets[0].Name)
+}
+
+func TestRepositoryIDCache(t *testing.T) {
+	lookups := 0
+	found := true
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lookups++
+				Repository(t, 42)(w, r)
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			getEnvironmentSecretsByRepositoryID,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !found {
+					mock.WriteError(w, http.StatusNotFound, "Not Found")
+					return
+				}
+				DependaBotSecrets(t)(w, r)
+			}),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+
+	for i := 0; i < 3; i++ {
+		_, err := client.ListEnvironmentSecrets("fr123k", "test_repo", "production")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, lookups)
+
+	// the repository may have been recreated, so its id is looked up again
+	found = false
+	_, err := client.ListEnvironmentSecrets("fr123k", "test_repo", "production")
+	assert.True(t, IsNotFound(err))
+	found = true
+	_, err = client.ListEnvironmentSecrets("fr123k", "test_repo", "production")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lookups)
+}
+
+func TestAddEnvironmentSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(Repository(t, 42)),
+		),
+		mock.WithRequestMatchHandler(
+			getEnvironmentPublicKeyByRepositoryID,
+			http.HandlerFunc(DependaBotPublicKey(t, "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
+		),
+		mock.WithRequestMatchHandler(
+			putEnvironmentSecretByRepositoryID,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.AddEnvironmentSecrets("fr123k", "test_repo", "production", "test_secret", "test_value")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "test_secret", secret.Name)
+	assert.True(t, len(secret.EncryptedValue) > 0)
+}
+
+func TestListEnvironmentVariables(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(Repository(t, 42)),
+		),
+		mock.WithRequestMatchPages(
+			getEnvironmentVariablesByRepositoryID,
+			github.ActionsVariables{
+				TotalCount: 2,
+				Variables:  []*github.ActionsVariable{{Name: "REGION", Value: "europe-west1"}},
+			},
+			github.ActionsVariables{
+				TotalCount: 2,
+				Variables:  []*github.ActionsVariable{{Name: "CLUSTER", Value: "prod"}},
+			},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	variables, err := client.ListEnvironmentVariables("fr123k", "test_repo", "production")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(variables.Variables))
+	assert.Equal(t, "REGION", variables.Variables[0].Name)
+	assert.Equal(t, "CLUSTER", variables.Variables[1].Name)
+}
+
+func TestAddEnvironmentVariable(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(Repository(t, 42)),
+		),
+		mock.WithRequestMatch(
+			postEnvironmentVariablesByRepositoryID,
+			nil,
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.AddEnvironmentVariable("fr123k", "test_repo", "production", "REGION", "europe-west1")
+
+	assert.NoError(t, err)
+}
+
+func TestListRepoVariables(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposActionsVariablesByOwnerByRepo,
+			github.ActionsVariables{
+				TotalCount: 1,
+				Variables:  []*github.ActionsVariable{{Name: "REGION", Value: "europe-west1"}},
+			},
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	variables, err := client.ListRepoVariables("fr123k", "test_repo")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(variables.Variables))
+	assert.Equal(t, "europe-west1", variables.Variables[0].Value)
+}
+
+func TestUpdateRepoVariable(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.PatchReposActionsVariablesByOwnerByRepoByName,
+			nil,
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.UpdateRepoVariable("fr123k", "test_repo", "REGION", "europe-west3")
+
+	assert.NoError(t, err)
+}
+
+func TestAddRepoVariableError(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PostReposActionsVariablesByOwnerByRepo,
+			http.HandlerFunc(ErrorStatus(t, http.StatusConflict)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.AddRepoVariable("fr123k", "test_repo", "REGION", "europe-west1")
+
+	assert.ErrorContains(t, err, "409 github went belly up or something")
+}
+
+func TestAddOrgVariable(t *testing.T) {
+	var variable github.ActionsVariable
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PostOrgsActionsVariablesByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&variable))
+			}),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	err := client.AddOrgVariable("test_org", "REGION", "europe-west1", "selected", []int64{42})
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "REGION", variable.Name)
+	assert.Equal(t, "selected", variable.GetVisibility())
+	assert.Equal(t, &github.SelectedRepoIDs{42}, variable.SelectedRepositoryIDs)
+}
+
+func TestListCodespacesSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposCodespacesSecretsByOwnerByRepo,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.ListCodespacesSecrets("fr123k", "test_repo")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(secret.Secrets))
+	assert.Equal(t, "Secret 1", secret.Secrets[0].Name)
+}
+
+func TestAddCodespacesSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PutReposCodespacesSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposCodespacesSecretsPublicKeyByOwnerByRepo,
+			http.HandlerFunc(DependaBotPublicKey(t, "aWk5RWlwaDlwdTVvaHNvaGZhM2FheTRDaGk1Ym9oeQo=")),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	secret, err := client.AddCodespacesSecrets("test_owner", "test_repo", "test_secret", "test_value")
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "test_secret", secret.Name)
+	assert.True(t, len(secret.EncryptedValue) > 0)
+}
+
+func TestAddOrgCodespacesSecrets(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PutOrgsCodespacesSecretsByOrgBySecretName,
+			http.HandlerFunc(DependaBotSecrets(t)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsCodespacesSecretsPublicKeyByOrg,
+			http.HandlerFunc(DependaBotPublicKey(t, "test_key")),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+	_, err := client.AddOrgCodespacesSecrets("test_org", "test_secret", "test_value", "private", nil)
+
+	assert.ErrorContains(t, err, "illegal base64 data at input byte 4")
+}
+
+func TestIsNotFound(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposActionsSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(ErrorStatus(t, http.StatusNotFound)),
+		),
+		mock.WithRequestMatchHandler(
+			mock.DeleteReposDependabotSecretsByOwnerByRepoBySecretName,
+			http.HandlerFunc(ErrorStatus(t, http.StatusInternalServerError)),
+		),
+	)
+	client := NewClient(config.Config{Owner: "fr123k"}, WithContext(context.Background()), WithClient(mockedHTTPClient))
+
+	err := client.RemoveActionsSecrets("fr123k", "test_repo", "removed")
+	assert.True(t, IsNotFound(err))
+
+	err = client.RemoveDependaBotSecrets("fr123k", "test_repo", "secret")
+	assert.Error(t, err)
+	assert.False(t, IsNotFound(err))
+	assert.False(t, IsNotFound(nil))
+}
@@ -0,0 +1,36 @@
+package source
+
+import (
+	"context"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/aws"
+)
+
+// AWSSecretsManager reads the secrets from AWS Secrets Manager.
+type AWSSecretsManager struct {
+	Client *aws.AWSClient
+}
+
+// Get reads the secret and returns the value of its property if it's a JSON secret with a property set.
+func (s AWSSecretsManager) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	secret, err := s.Client.GetSecretValue(ref.Region, ref.Key, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	return withProperty(&Secret{Value: secret.Value, Version: secret.Version}, ref.Key, ref.Property)
+}
+
+// AWSParameterStore reads the secrets from the SSM Parameter Store.
+type AWSParameterStore struct {
+	Client *aws.AWSClient
+}
+
+// Get reads the parameter and returns the value of its property if it's a JSON parameter with a property set.
+func (s AWSParameterStore) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	secret, err := s.Client.GetParameter(ref.Region, ref.Key, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	return withProperty(&Secret{Value: secret.Value, Version: secret.Version}, ref.Key, ref.Property)
+}
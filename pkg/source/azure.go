@@ -0,0 +1,21 @@
+package source
+
+import (
+	"context"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/azure"
+)
+
+// AzureKeyVault reads the secrets from Azure Key Vaults.
+type AzureKeyVault struct {
+	Client *azure.AzureClient
+}
+
+func (s AzureKeyVault) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	secret, err := s.Client.GetSecret(ref.VaultURL, ref.Key, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	return withProperty(&Secret{Value: secret.Value, Version: secret.Version}, ref.Key, ref.Property)
+}
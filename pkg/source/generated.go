@@ -0,0 +1,208 @@
+package source
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+const (
+	// GeneratedAnnotationPrefix prefixes the annotations of the Kubernetes Secret that record the
+	// regenerate value each key was generated with.
+	GeneratedAnnotationPrefix = "generated.secret.fr123k.uk/"
+
+	defaultPasswordLength  = 32
+	defaultPasswordCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	defaultRSABits         = 4096
+)
+
+type ownerKey struct{}
+
+// WithOwner returns a context with the custom resource that owns the Kubernetes Secrets of
+// the generated values, so they're garbage collected together with it.
+func WithOwner(ctx context.Context, owner client.Object) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// Generated creates random passwords and SSH keypairs. A value is generated once and stored under
+// the key of the Kubernetes Secret secretName, the public key of a keypair under <key>.pub. It's only
+// generated again if the key is removed from the Secret or the regenerate field of the spec changes.
+type Generated struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+	// VersionKey keys the versions of the values.
+	VersionKey []byte
+}
+
+func (s Generated) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+	if namespace == "" || ref.SecretName == "" {
+		return nil, fmt.Errorf("the Generated source of key %s requires a secretName and namespace", ref.Key)
+	}
+	spec := secretv1alpha1.GeneratedSecret{}
+	if ref.Generate != nil {
+		spec = *ref.Generate
+	}
+	// zero is the default, the CRD rejects it when it's set explicitly
+	if spec.Length < 0 || spec.Bits < 0 {
+		return nil, fmt.Errorf("the length and bits of generated key %s must be at least 1", ref.Key)
+	}
+
+	secret := &v1.Secret{}
+	err := s.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.SecretName}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+
+	annotation := GeneratedAnnotationPrefix + ref.Key
+	value, ok := secret.Data[ref.Key]
+	if ok && secret.Annotations[annotation] == spec.Regenerate {
+		return s.generatedSecret(value)
+	}
+
+	owner, hasOwner := ctx.Value(ownerKey{}).(client.Object)
+	if exists && hasOwner && !ownedBy(secret, owner) {
+		// the Secret would be garbage collected together with the custom resource
+		return nil, fmt.Errorf("secret %s/%s of generated key %s isn't owned by %s", namespace, ref.SecretName, ref.Key, owner.GetName())
+	}
+
+	generated, err := generate(spec, ref.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		secret = &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: ref.SecretName, Namespace: namespace}}
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	for key, data := range generated {
+		secret.Data[key] = data
+	}
+	secret.Annotations[annotation] = spec.Regenerate
+	if hasOwner {
+		err = controllerutil.SetOwnerReference(owner, secret, s.Scheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// a conflict fails the read instead of overwriting a value that was generated concurrently
+	if exists {
+		err = s.Client.Update(ctx, secret)
+	} else {
+		err = s.Client.Create(ctx, secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store generated key %s in secret %s/%s. Error:%w", ref.Key, namespace, ref.SecretName, err)
+	}
+	return s.generatedSecret(generated[ref.Key])
+}
+
+// ownedBy tells whether the Secret is owned by the custom resource, which is the case for the
+// Secrets it created.
+func ownedBy(secret *v1.Secret, owner client.Object) bool {
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedSecret returns the value with a keyed hash of it as version, like the Kubernetes source.
+func (s Generated) generatedSecret(value []byte) (*Secret, error) {
+	version, err := keyedVersion(s.VersionKey, value)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{Value: string(value), Version: version}, nil
+}
+
+// generate returns the data of the generated value, the private and public key for keypairs.
+func generate(spec secretv1alpha1.GeneratedSecret, key string) (map[string][]byte, error) {
+	switch spec.Type {
+	case "", secretv1alpha1.GeneratedTypePassword:
+		password, err := generatePassword(spec.Length, spec.Charset)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{key: password}, nil
+	case secretv1alpha1.GeneratedTypeEd25519:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return keypair(key, privateKey)
+	case secretv1alpha1.GeneratedTypeRSA:
+		bits := spec.Bits
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, err
+		}
+		return keypair(key, privateKey)
+	default:
+		return nil, fmt.Errorf("unknown type %s of generated key %s", spec.Type, key)
+	}
+}
+
+func generatePassword(length int, charset string) ([]byte, error) {
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+	if charset == "" {
+		charset = defaultPasswordCharset
+	}
+	chars := []rune(charset)
+
+	var password []rune
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return nil, err
+		}
+		password = append(password, chars[n.Int64()])
+	}
+	return []byte(string(password)), nil
+}
+
+// keypair returns the private key in OpenSSH format and the public key in authorized_keys format,
+// which is what Github expects for deploy keys.
+func keypair(key string, privateKey interface{}) (map[string][]byte, error) {
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		key:          pem.EncodeToMemory(block),
+		key + ".pub": ssh.MarshalAuthorizedKey(signer.PublicKey()),
+	}, nil
+}
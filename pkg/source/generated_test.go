@@ -0,0 +1,120 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+func newGeneratedSource(t *testing.T) Generated {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, secretv1alpha1.AddToScheme(scheme))
+	return Generated{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme, VersionKey: versionKey}
+}
+
+func generatedKubernetesSecret(t *testing.T, s Generated) *v1.Secret {
+	secret := &v1.Secret{}
+	assert.NoError(t, s.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "generated"}, secret))
+	return secret
+}
+
+func TestGeneratedPassword(t *testing.T) {
+	s := newGeneratedSource(t)
+	owner := &secretv1alpha1.GithubSecret{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "uid"}}
+	ctx := WithOwner(context.Background(), owner)
+	ref := secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "webhook", SecretName: "generated",
+		Generate: &secretv1alpha1.GeneratedSecret{Length: 48, Charset: "abc"}}
+
+	first, err := s.Get(ctx, "default", ref)
+	assert.NoError(t, err)
+	assert.Len(t, first.Value, 48)
+	assert.Empty(t, strings.Trim(first.Value, "abc"))
+
+	secret := generatedKubernetesSecret(t, s)
+	assert.Equal(t, first.Value, string(secret.Data["webhook"]))
+	assert.Equal(t, "app", secret.OwnerReferences[0].Name)
+
+	// the value is never generated again, not even if the spec changes
+	ref.Generate.Length = 12
+	second, err := s.Get(ctx, "default", ref)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	// unless regenerate changes
+	ref.Generate.Regenerate = "2024-06-01"
+	third, err := s.Get(ctx, "default", ref)
+	assert.NoError(t, err)
+	assert.Len(t, third.Value, 12)
+	assert.NotEqual(t, first.Version, third.Version)
+	assert.Equal(t, "2024-06-01", generatedKubernetesSecret(t, s).Annotations[GeneratedAnnotationPrefix+"webhook"])
+
+	// other keys are added to the same Secret
+	other, err := s.Get(ctx, "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "token", SecretName: "generated"})
+	assert.NoError(t, err)
+	assert.Len(t, other.Value, 32)
+	assert.Equal(t, third.Value, string(generatedKubernetesSecret(t, s).Data["webhook"]))
+}
+
+func TestGeneratedUnownedSecret(t *testing.T) {
+	s := newGeneratedSource(t)
+	unowned := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "generated", Namespace: "default"}, Data: map[string][]byte{"other": []byte("value")}}
+	assert.NoError(t, s.Client.Create(context.Background(), unowned))
+	owner := &secretv1alpha1.GithubSecret{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "uid"}}
+	ctx := WithOwner(context.Background(), owner)
+
+	_, err := s.Get(ctx, "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "webhook", SecretName: "generated"})
+	assert.EqualError(t, err, "secret default/generated of generated key webhook isn't owned by app")
+
+	// the Secret isn't touched, so it isn't garbage collected together with the GithubSecret
+	secret := generatedKubernetesSecret(t, s)
+	assert.Empty(t, secret.OwnerReferences)
+	assert.Equal(t, map[string][]byte{"other": []byte("value")}, secret.Data)
+}
+
+func TestGeneratedKeypairs(t *testing.T) {
+	s := newGeneratedSource(t)
+
+	for _, keyType := range []string{secretv1alpha1.GeneratedTypeEd25519, secretv1alpha1.GeneratedTypeRSA} {
+		ref := secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "deploy-" + strings.ToLower(keyType), SecretName: "generated",
+			Generate: &secretv1alpha1.GeneratedSecret{Type: keyType, Bits: 2048}}
+
+		private, err := s.Get(context.Background(), "default", ref)
+		assert.NoError(t, err)
+		signer, err := ssh.ParsePrivateKey([]byte(private.Value))
+		assert.NoError(t, err)
+
+		public := generatedKubernetesSecret(t, s).Data[ref.Key+".pub"]
+		assert.Equal(t, string(ssh.MarshalAuthorizedKey(signer.PublicKey())), string(public))
+	}
+}
+
+func TestGeneratedErrors(t *testing.T) {
+	s := newGeneratedSource(t)
+
+	_, err := s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "token"})
+	assert.EqualError(t, err, "the Generated source of key token requires a secretName and namespace")
+
+	_, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "token", SecretName: "generated",
+		Generate: &secretv1alpha1.GeneratedSecret{Type: "DSA"}})
+	assert.EqualError(t, err, "unknown type DSA of generated key token")
+
+	_, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "token", SecretName: "generated",
+		Generate: &secretv1alpha1.GeneratedSecret{Length: -1}})
+	assert.EqualError(t, err, "the length and bits of generated key token must be at least 1")
+
+	_, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceGenerated, Key: "token", SecretName: "generated",
+		Generate: &secretv1alpha1.GeneratedSecret{Type: secretv1alpha1.GeneratedTypeRSA, Bits: -4096}})
+	assert.EqualError(t, err, "the length and bits of generated key token must be at least 1")
+}
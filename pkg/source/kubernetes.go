@@ -0,0 +1,47 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+// Kubernetes reads the secrets from a key of a Kubernetes Secret. Namespaced resources read the
+// Secrets of their own namespace, cluster scoped resources the namespace of the reference.
+type Kubernetes struct {
+	Client client.Reader
+	// VersionKey keys the versions of the values.
+	VersionKey []byte
+}
+
+// Get reads the key of the Kubernetes Secret. The version is a keyed hash of the value, so changes
+// to other keys of the Secret don't push the secret again.
+func (s Kubernetes) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+	if namespace == "" || ref.SecretName == "" {
+		return nil, fmt.Errorf("the Kubernetes source of key %s requires a secretName and namespace", ref.Key)
+	}
+
+	secret := &v1.Secret{}
+	err := s.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.SecretName}, secret)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in secret %s/%s", ref.Key, namespace, ref.SecretName)
+	}
+
+	version, err := keyedVersion(s.VersionKey, value)
+	if err != nil {
+		return nil, err
+	}
+	return withProperty(&Secret{Value: string(value), Version: version}, ref.Key, ref.Property)
+}
@@ -0,0 +1,67 @@
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+func TestKubernetesGet(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": []byte("certificate")},
+	}).Build()
+
+	ref := secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "tls", Key: "tls.crt"}
+	value, err := Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "certificate", value.Value)
+	assert.NotEmpty(t, value.Version)
+
+	ref.Key = "tls.key"
+	_, err = Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.EqualError(t, err, "key tls.key not found in secret default/tls")
+
+	_, err = Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "other", ref)
+	assert.Error(t, err)
+
+	_, err = Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "", ref)
+	assert.Error(t, err)
+
+	// cluster scoped resources read the namespace of the reference
+	ref.Key = "tls.crt"
+	ref.Namespace = "default"
+	value, err = Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "", ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "certificate", value.Value)
+}
+
+func TestKubernetesSecretVersionChangesWithValue(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": []byte("certificate"), "tls.key": []byte("key")},
+	}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+	ref := secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceKubernetes, SecretName: "tls", Key: "tls.crt"}
+
+	first, err := Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.NoError(t, err)
+
+	secret.Data["tls.key"] = []byte("rotated key")
+	assert.NoError(t, c.Update(context.Background(), secret))
+	second, err := Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Version, second.Version)
+
+	secret.Data["tls.crt"] = []byte("rotated certificate")
+	assert.NoError(t, c.Update(context.Background(), secret))
+	third, err := Kubernetes{Client: c, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Version, third.Version)
+}
@@ -0,0 +1,117 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// PropertyError is returned if the property of a structured secret can't be resolved.
+type PropertyError struct {
+	Err error
+}
+
+func (e *PropertyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PropertyError) Unwrap() error {
+	return e.Err
+}
+
+// withProperty returns the secret with its value replaced by the property of the structured JSON or
+// YAML secret, or the secret unchanged if no property is set. The property is either a top level key
+// or a JSONPath expression like $.database.password or {.keys[0].id}. Values that aren't strings are
+// returned as JSON.
+func withProperty(secret *Secret, key string, property string) (*Secret, error) {
+	if property == "" {
+		return secret, nil
+	}
+
+	data, err := structuredValue(secret.Value)
+	if err != nil {
+		return nil, &PropertyError{Err: fmt.Errorf("secret %s is not a JSON or YAML document, can't read property %s", key, property)}
+	}
+
+	var value interface{}
+	if isJSONPath(property) {
+		value, err = jsonPathValue(data, key, property)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, &PropertyError{Err: fmt.Errorf("secret %s is not a JSON or YAML object, can't read property %s", key, property)}
+		}
+		value, ok = object[property]
+		if !ok {
+			return nil, &PropertyError{Err: fmt.Errorf("property %s not found in secret %s", property, key)}
+		}
+	}
+
+	if str, ok := value.(string); ok {
+		return &Secret{Value: str, Version: secret.Version}, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{Value: string(raw), Version: secret.Version}, nil
+}
+
+// structuredValue parses the JSON or YAML value, numbers are kept as they are.
+func structuredValue(value string) (interface{}, error) {
+	data, err := yaml.YAMLToJSON([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var result interface{}
+	err = decoder.Decode(&result)
+	return result, err
+}
+
+// isJSONPath tells JSONPath expressions apart from top level keys, which may contain dots.
+func isJSONPath(property string) bool {
+	return strings.HasPrefix(property, "$") || strings.HasPrefix(property, "{")
+}
+
+// jsonPathValue returns the value the JSONPath expression resolves to, a list of values
+// if it resolves to more than one.
+func jsonPathValue(data interface{}, key string, property string) (interface{}, error) {
+	expression := property
+	if strings.HasPrefix(expression, "$") {
+		expression = fmt.Sprintf("{%s}", strings.TrimPrefix(expression, "$"))
+	}
+
+	path := jsonpath.New(key)
+	err := path.Parse(expression)
+	if err != nil {
+		return nil, &PropertyError{Err: fmt.Errorf("invalid property %s of secret %s. Error:%w", property, key, err)}
+	}
+	results, err := path.FindResults(data)
+	if err != nil {
+		return nil, &PropertyError{Err: fmt.Errorf("property %s not found in secret %s. Error:%w", property, key, err)}
+	}
+
+	var values []interface{}
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, &PropertyError{Err: fmt.Errorf("property %s not found in secret %s", property, key)}
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
@@ -0,0 +1,79 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithProperty(t *testing.T) {
+	secret := &Secret{Value: `{"username":"admin","port":5432,"tags":["a"]}`, Version: "1"}
+
+	value, err := withProperty(secret, "db", "")
+	assert.NoError(t, err)
+	assert.Equal(t, secret, value)
+
+	value, err = withProperty(secret, "db", "username")
+	assert.NoError(t, err)
+	assert.Equal(t, &Secret{Value: "admin", Version: "1"}, value)
+
+	value, err = withProperty(secret, "db", "port")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", value.Value)
+
+	value, err = withProperty(secret, "db", "tags")
+	assert.NoError(t, err)
+	assert.Equal(t, `["a"]`, value.Value)
+
+	_, err = withProperty(secret, "db", "password")
+	assert.EqualError(t, err, "property password not found in secret db")
+
+	_, err = withProperty(&Secret{Value: "plain"}, "db", "password")
+	assert.EqualError(t, err, "secret db is not a JSON or YAML object, can't read property password")
+	var propertyErr *PropertyError
+	assert.ErrorAs(t, err, &propertyErr)
+}
+
+func TestWithPropertyJSONPath(t *testing.T) {
+	secret := &Secret{Value: `{"type":"service_account","database":{"user":"admin","port":5432},"keys":[{"id":"a"},{"id":"b"}]}`, Version: "1"}
+
+	value, err := withProperty(secret, "db", "$.database.user")
+	assert.NoError(t, err)
+	assert.Equal(t, &Secret{Value: "admin", Version: "1"}, value)
+
+	value, err = withProperty(secret, "db", "{.database.port}")
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", value.Value)
+
+	value, err = withProperty(secret, "db", "$.database")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"port":5432,"user":"admin"}`, value.Value)
+
+	value, err = withProperty(secret, "db", "$.keys[1].id")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", value.Value)
+
+	value, err = withProperty(secret, "db", "$.keys[*].id")
+	assert.NoError(t, err)
+	assert.Equal(t, `["a","b"]`, value.Value)
+
+	_, err = withProperty(secret, "db", "$.database.password")
+	assert.EqualError(t, err, "property $.database.password not found in secret db. Error:password is not found")
+	var propertyErr *PropertyError
+	assert.ErrorAs(t, err, &propertyErr)
+
+	_, err = withProperty(secret, "db", "$.keys[")
+	assert.ErrorContains(t, err, "invalid property $.keys[ of secret db")
+}
+
+func TestWithPropertyYAML(t *testing.T) {
+	secret := &Secret{Value: "database:\n  user: admin\n  port: 5432\n", Version: "1"}
+
+	value, err := withProperty(secret, "db", "$.database.user")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", value.Value)
+
+	value, err = withProperty(secret, "db", "database")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"port":5432,"user":"admin"}`, value.Value)
+}
@@ -0,0 +1,179 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	filippoage "filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/pgp"
+	sopsyaml "github.com/getsops/sops/v3/stores/yaml"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+const sopsPlain = `database:
+  password: secret
+  port: 5432
+token: abc
+`
+
+// encryptSOPS encrypts the YAML document the same way the sops CLI does for the given key group.
+func encryptSOPS(t *testing.T, plain string, group sops.KeyGroup, dataKey []byte) string {
+	store := sopsyaml.NewStore(&config.YAMLStoreConfig{})
+	branches, err := store.LoadPlainFile([]byte(plain))
+	assert.NoError(t, err)
+
+	tree := sops.Tree{
+		Branches: branches,
+		Metadata: sops.Metadata{
+			KeyGroups:         []sops.KeyGroup{group},
+			UnencryptedSuffix: "_unencrypted",
+			Version:           "3.13.3",
+			LastModified:      time.Now().UTC().Truncate(time.Second),
+		},
+	}
+	cipher := aes.NewCipher()
+	mac, err := tree.Encrypt(dataKey, cipher)
+	assert.NoError(t, err)
+	tree.Metadata.MessageAuthenticationCode, err = cipher.Encrypt(mac, dataKey, tree.Metadata.LastModified.Format(time.RFC3339))
+	assert.NoError(t, err)
+
+	document, err := store.EmitEncryptedFile(tree)
+	assert.NoError(t, err)
+	return string(document)
+}
+
+func newDataKey(t *testing.T) []byte {
+	dataKey := make([]byte, 32)
+	_, err := rand.Read(dataKey)
+	assert.NoError(t, err)
+	return dataKey
+}
+
+// ageDocument returns a document encrypted for a new age identity and the directory with the identity.
+func ageDocument(t *testing.T) (string, string) {
+	identity, err := filippoage.GenerateX25519Identity()
+	assert.NoError(t, err)
+	keysPath := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(keysPath, "keys.txt"), []byte("# sops\n"+identity.String()+"\n"), 0o600))
+
+	dataKey := newDataKey(t)
+	key, err := age.MasterKeyFromRecipient(identity.Recipient().String())
+	assert.NoError(t, err)
+	assert.NoError(t, key.Encrypt(dataKey))
+	return encryptSOPS(t, sopsPlain, sops.KeyGroup{key}, dataKey), keysPath
+}
+
+func TestSOPSGetInline(t *testing.T) {
+	document, keysPath := ageDocument(t)
+	s := SOPS{KeysPath: keysPath, VersionKey: versionKey}
+
+	secret, err := s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "token", Encrypted: document})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", secret.Value)
+	assert.NotEmpty(t, secret.Version)
+
+	secret, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "database", Property: "password", Encrypted: document})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", secret.Value)
+
+	secret, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "database", Encrypted: document})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"password":"secret","port":5432}`, secret.Value)
+
+	_, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "missing", Encrypted: document})
+	assert.EqualError(t, err, "property missing not found in secret encrypted")
+}
+
+func TestSOPSGetConfigMap(t *testing.T) {
+	document, keysPath := ageDocument(t)
+	c := fake.NewClientBuilder().WithObjects(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "secrets", Namespace: "default"},
+		Data:       map[string]string{"secrets.yaml": document},
+	}).Build()
+	s := SOPS{Client: c, KeysPath: keysPath, VersionKey: versionKey}
+
+	ref := secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "token", ConfigMapName: "secrets", ConfigMapKey: "secrets.yaml"}
+	secret, err := s.Get(context.Background(), "default", ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", secret.Value)
+
+	// cluster scoped resources read the namespace of the reference
+	ref.Namespace = "default"
+	secret, err = s.Get(context.Background(), "", ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", secret.Value)
+
+	ref.ConfigMapKey = "other.yaml"
+	_, err = s.Get(context.Background(), "default", ref)
+	assert.EqualError(t, err, "key other.yaml not found in config map default/secrets")
+
+	_, err = s.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "token"})
+	assert.EqualError(t, err, "the SOPS source of key token requires either encrypted or a configMapName, configMapKey and namespace")
+}
+
+func TestSOPSGetPGP(t *testing.T) {
+	entity, err := openpgp.NewEntity("github-operator", "", "github-operator@example.com", nil)
+	assert.NoError(t, err)
+	var private bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.SerializePrivate(w, nil))
+	assert.NoError(t, w.Close())
+	keysPath := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(keysPath, "private.asc"), private.Bytes(), 0o600))
+
+	dataKey := newDataKey(t)
+	var encrypted bytes.Buffer
+	aw, err := armor.Encode(&encrypted, "PGP MESSAGE", nil)
+	assert.NoError(t, err)
+	pw, err := openpgp.Encrypt(aw, openpgp.EntityList{entity}, nil, nil, nil)
+	assert.NoError(t, err)
+	_, err = pw.Write(dataKey)
+	assert.NoError(t, err)
+	assert.NoError(t, pw.Close())
+	assert.NoError(t, aw.Close())
+	key := pgp.NewMasterKeyFromFingerprint(fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint))
+	key.SetEncryptedDataKey(encrypted.Bytes())
+
+	document := encryptSOPS(t, sopsPlain, sops.KeyGroup{key}, dataKey)
+	secret, err := SOPS{KeysPath: keysPath, VersionKey: versionKey}.Get(context.Background(), "default", secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "token", Encrypted: document})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", secret.Value)
+}
+
+func TestSOPSGetFailures(t *testing.T) {
+	document, keysPath := ageDocument(t)
+	ref := secretv1alpha1.SecretRef{Source: secretv1alpha1.SecretSourceSOPS, Key: "token", Encrypted: document}
+
+	// a document encrypted for another identity can't be decrypted
+	_, otherKeysPath := ageDocument(t)
+	_, err := SOPS{KeysPath: otherKeysPath, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.ErrorContains(t, err, "failed to decrypt SOPS document encrypted")
+
+	_, err = SOPS{KeysPath: t.TempDir(), VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.ErrorContains(t, err, "no SOPS keys found")
+
+	// a modified document fails the integrity check
+	modified := strings.Replace(document, "lastmodified:", "lastmodified: \"2001-01-01T00:00:00Z\"\n    old_lastmodified:", 1)
+	ref.Encrypted = modified
+	_, err = SOPS{KeysPath: keysPath, VersionKey: versionKey}.Get(context.Background(), "default", ref)
+	assert.ErrorContains(t, err, "MAC")
+}
@@ -0,0 +1,128 @@
+package source
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+// Secret is the value of a secret together with the version it was read from.
+// A secret is pushed to Github again as soon as its version changes.
+type Secret struct {
+	Value   string
+	Version string
+}
+
+// SecretSource resolves a secret reference to its value.
+type SecretSource interface {
+	// Get reads the referenced secret. The namespace is the one of the custom resource,
+	// it's empty for cluster scoped resources.
+	Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error)
+}
+
+// UnknownSourceError is returned for secret references to a source that isn't registered.
+type UnknownSourceError struct {
+	Source string
+}
+
+func (e *UnknownSourceError) Error() string {
+	return fmt.Sprintf("unknown secret source %s", e.Source)
+}
+
+type cacheKey struct{}
+
+type readKey struct {
+	namespace string
+	ref       secretv1alpha1.SecretRef
+}
+
+type read struct {
+	secret *Secret
+	err    error
+}
+
+type cache struct {
+	mu    sync.Mutex
+	reads map[readKey]read
+}
+
+// WithCache returns a context in which the registry reads every secret reference only once,
+// so the secrets shared by several repositories are read once per reconcile pass.
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheKey{}, &cache{reads: map[readKey]read{}})
+}
+
+type entry struct {
+	source        SecretSource
+	conditionType string
+}
+
+// Registry dispatches the secret references to the source registered for their Source.
+type Registry struct {
+	sources map[string]entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{sources: map[string]entry{}}
+}
+
+// Register adds the source under the given name. The failures of the source are reported
+// with the given condition type.
+func (r *Registry) Register(name string, conditionType string, source SecretSource) {
+	r.sources[name] = entry{source: source, conditionType: conditionType}
+}
+
+// Get reads the referenced secret from its source, references without a source use GCP.
+func (r *Registry) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	e, ok := r.sources[sourceName(ref)]
+	if !ok {
+		return nil, &UnknownSourceError{Source: ref.Source}
+	}
+	c, ok := ctx.Value(cacheKey{}).(*cache)
+	if !ok {
+		return e.source.Get(ctx, namespace, ref)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := readKey{namespace: namespace, ref: ref}
+	if read, ok := c.reads[key]; ok {
+		return read.secret, read.err
+	}
+	secret, err := e.source.Get(ctx, namespace, ref)
+	c.reads[key] = read{secret: secret, err: err}
+	return secret, err
+}
+
+// ConditionType returns the condition reporting the failures of the referenced source.
+func (r *Registry) ConditionType(ref secretv1alpha1.SecretRef) string {
+	e, ok := r.sources[sourceName(ref)]
+	if !ok {
+		return secretv1alpha1.ConditionTypeUnknownSecretSource
+	}
+	return e.conditionType
+}
+
+// keyedVersion returns the version of a value whose source doesn't version it. It's an HMAC keyed
+// with the version key of the operator, so the version recorded in the status doesn't allow to
+// guess the value.
+func keyedVersion(key []byte, value []byte) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("a version key is required to version the values of the source")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(value)
+	return fmt.Sprintf("%x", mac.Sum(nil))[:16], nil
+}
+
+func sourceName(ref secretv1alpha1.SecretRef) string {
+	if ref.Source == "" {
+		return secretv1alpha1.SecretSourceGCP
+	}
+	return ref.Source
+}
@@ -0,0 +1,92 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+)
+
+// versionKey keys the versions of the sources that don't version their values.
+var versionKey = []byte("version-key")
+
+type staticSource map[string]string
+
+func (s staticSource) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	return &Secret{Value: s[ref.Key], Version: "1"}, nil
+}
+
+func TestRegistryGet(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("GCP", secretv1alpha1.ConditionTypeGCPSecretManagerError, staticSource{"token": "gcp"})
+	registry.Register("Static", "StaticSourceError", staticSource{"token": "static"})
+
+	secret, err := registry.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "token", Source: "Static"})
+	assert.NoError(t, err)
+	assert.Equal(t, "static", secret.Value)
+	assert.Equal(t, "StaticSourceError", registry.ConditionType(secretv1alpha1.SecretRef{Source: "Static"}))
+
+	// references without a source use GCP
+	secret, err = registry.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "token"})
+	assert.NoError(t, err)
+	assert.Equal(t, "gcp", secret.Value)
+	assert.Equal(t, secretv1alpha1.ConditionTypeGCPSecretManagerError, registry.ConditionType(secretv1alpha1.SecretRef{}))
+}
+
+func TestRegistryUnknownSource(t *testing.T) {
+	registry := NewRegistry()
+
+	secret, err := registry.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "token", Source: "Vault"})
+	assert.Nil(t, secret)
+	assert.EqualError(t, err, "unknown secret source Vault")
+	assert.IsType(t, &UnknownSourceError{}, err)
+	assert.Equal(t, secretv1alpha1.ConditionTypeUnknownSecretSource, registry.ConditionType(secretv1alpha1.SecretRef{Source: "Vault"}))
+}
+
+// countingSource counts the reads per key.
+type countingSource map[string]int
+
+func (s countingSource) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	s[ref.Key]++
+	return &Secret{Value: ref.Key, Version: "1"}, nil
+}
+
+func TestRegistryCache(t *testing.T) {
+	reads := countingSource{}
+	registry := NewRegistry()
+	registry.Register("GCP", secretv1alpha1.ConditionTypeGCPSecretManagerError, reads)
+
+	ctx := WithCache(context.Background())
+	for i := 0; i < 3; i++ {
+		secret, err := registry.Get(ctx, "default", secretv1alpha1.SecretRef{Key: "token"})
+		assert.NoError(t, err)
+		assert.Equal(t, "token", secret.Value)
+	}
+	_, err := registry.Get(ctx, "default", secretv1alpha1.SecretRef{Key: "token", Version: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, countingSource{"token": 2}, reads)
+
+	// without the cache every reference is read again
+	_, err = registry.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "token"})
+	assert.NoError(t, err)
+	assert.Equal(t, countingSource{"token": 3}, reads)
+}
+
+func TestKeyedVersion(t *testing.T) {
+	version, err := keyedVersion(versionKey, []byte("secret"))
+	assert.NoError(t, err)
+	assert.Len(t, version, 16)
+	// the version doesn't reveal a hash of the value
+	assert.NotEqual(t, fmt.Sprintf("%x", sha256.Sum256([]byte("secret")))[:16], version)
+
+	other, err := keyedVersion([]byte("other-key"), []byte("secret"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, version, other)
+
+	_, err = keyedVersion(nil, []byte("secret"))
+	assert.EqualError(t, err, "a version key is required to version the values of the source")
+}
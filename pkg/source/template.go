@@ -0,0 +1,68 @@
+package source
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/template"
+)
+
+// templateFuncs are the functions available in the templates in addition to the builtin ones,
+// for example to build the auth of a Docker config.json.
+var templateFuncs = template.FuncMap{
+	"b64enc": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"b64dec": func(s string) (string, error) {
+		data, err := base64.StdEncoding.DecodeString(s)
+		return string(data), err
+	},
+	"toJson": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Render executes the Go text/template with the values of the inputs, available as {{ .<name> }}.
+// The version is a hash of the template and the versions of the inputs rather than of the rendered
+// value, so the secret is pushed again as soon as an input or the template changes.
+func Render(name string, text string, inputs map[string]*Secret) (*Secret, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template of secret %s. Error:%w", name, err)
+	}
+
+	values := make(map[string]string, len(inputs))
+	for input, secret := range inputs {
+		values[input] = secret.Value
+	}
+	var rendered bytes.Buffer
+	err = tmpl.Execute(&rendered, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template of secret %s. Error:%w", name, err)
+	}
+
+	return &Secret{
+		Value:   rendered.String(),
+		Version: templateVersion(text, inputs),
+	}, nil
+}
+
+// templateVersion hashes the template together with the names and versions of the inputs.
+func templateVersion(text string, inputs map[string]*Secret) string {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	hash.Write([]byte(text))
+	for _, name := range names {
+		fmt.Fprintf(hash, "\x00%s\x00%s", name, inputs[name].Version)
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:16]
+}
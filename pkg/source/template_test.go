@@ -0,0 +1,44 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderDockerConfig(t *testing.T) {
+	inputs := map[string]*Secret{
+		"user":     {Value: "robot", Version: "1"},
+		"password": {Value: "secret", Version: "2"},
+	}
+
+	secret, err := Render("DOCKER_CONFIG", `{"auths":{"ghcr.io":{"auth":{{ printf "%s:%s" .user .password | b64enc | toJson }}}}}`, inputs)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"auths":{"ghcr.io":{"auth":"cm9ib3Q6c2VjcmV0"}}}`, secret.Value)
+	assert.NotEmpty(t, secret.Version)
+
+	dsn := `postgres://{{ .user }}:{{ .password | urlquery }}@db:5432/app`
+	first, err := Render("DSN", dsn, inputs)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://robot:secret@db:5432/app", first.Value)
+
+	inputs["password"] = &Secret{Value: "rotated", Version: "3"}
+	second, err := Render("DSN", dsn, inputs)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Version, second.Version)
+
+	// the version is derived from the versions of the inputs, not from the rendered value
+	inputs["password"] = &Secret{Value: "other", Version: "3"}
+	third, err := Render("DSN", dsn, inputs)
+	assert.NoError(t, err)
+	assert.NotEqual(t, second.Value, third.Value)
+	assert.Equal(t, second.Version, third.Version)
+}
+
+func TestRenderErrors(t *testing.T) {
+	_, err := Render("DSN", `{{ .user`, nil)
+	assert.ErrorContains(t, err, "failed to parse template of secret DSN")
+
+	_, err = Render("DSN", `{{ .host }}`, map[string]*Secret{"user": {Value: "robot"}})
+	assert.ErrorContains(t, err, "failed to render template of secret DSN")
+}
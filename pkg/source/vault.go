@@ -0,0 +1,38 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/vault"
+)
+
+// Vault reads the secrets from the KV secrets engines of HashiCorp Vault.
+type Vault struct {
+	Client *vault.VaultClient
+	// VersionKey keys the versions of the KV version 1 secrets, which aren't versioned.
+	VersionKey []byte
+}
+
+// Get reads the secret at the path of the key and returns the value of its property,
+// or the whole secret as JSON if no property is set.
+func (s Vault) Get(ctx context.Context, namespace string, ref secretv1alpha1.SecretRef) (*Secret, error) {
+	secret, err := s.Client.GetSecret(ref.Key, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(secret.Data)
+	if err != nil {
+		return nil, err
+	}
+	version := secret.Version
+	if version == "" {
+		version, err = keyedVersion(s.VersionKey, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return withProperty(&Secret{Value: string(data), Version: version}, ref.Key, ref.Property)
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	secretv1alpha1 "github.com/fr123k/github-operator/api/v1alpha1"
+	"github.com/fr123k/github-operator/pkg/config"
+	"github.com/fr123k/github-operator/pkg/vault"
+)
+
+func newVaultSource(t *testing.T) Vault {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body interface{}
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			body = map[string]interface{}{"data": map[string]interface{}{"ttl": 0}}
+		case "/v1/sys/internal/ui/mounts/secret/app":
+			body = map[string]interface{}{"data": map[string]interface{}{"path": "secret/", "options": map[string]interface{}{"version": "2"}}}
+		case "/v1/secret/data/app":
+			body = map[string]interface{}{"data": map[string]interface{}{
+				"data":     map[string]interface{}{"username": "admin", "password": "secret", "port": 5432},
+				"metadata": map[string]interface{}{"version": 3},
+			}}
+		case "/v1/sys/internal/ui/mounts/kv/app":
+			body = map[string]interface{}{"data": map[string]interface{}{"path": "kv/", "options": map[string]interface{}{"version": "1"}}}
+		case "/v1/kv/app":
+			body = map[string]interface{}{"data": map[string]interface{}{"password": "secret"}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := vault.NewClient(config.Config{VaultAddress: server.URL, VaultAuthMethod: vault.AuthMethodToken, VaultToken: "root"})
+	assert.NoError(t, err)
+	return Vault{Client: client, VersionKey: []byte("version-key")}
+}
+
+func TestVaultGetProperty(t *testing.T) {
+	source := newVaultSource(t)
+
+	secret, err := source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "secret/app", Property: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Secret{Value: "secret", Version: "3"}, secret)
+
+	secret, err = source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "secret/app", Property: "port"})
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", secret.Value)
+
+	_, err = source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "secret/app", Property: "token"})
+	assert.EqualError(t, err, "property token not found in secret secret/app")
+}
+
+func TestVaultGetWholeSecret(t *testing.T) {
+	source := newVaultSource(t)
+
+	secret, err := source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "secret/app"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"username":"admin","password":"secret","port":5432}`, secret.Value)
+}
+
+func TestVaultGetKVv1(t *testing.T) {
+	source := newVaultSource(t)
+
+	secret, err := source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "kv/app", Property: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", secret.Value)
+	assert.NotEmpty(t, secret.Version)
+
+	// KV version 1 secrets aren't versioned, their version is keyed with the version key
+	source.VersionKey = []byte("other-key")
+	other, err := source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "kv/app", Property: "password"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret.Version, other.Version)
+
+	source.VersionKey = nil
+	_, err = source.Get(context.Background(), "default", secretv1alpha1.SecretRef{Key: "kv/app", Property: "password"})
+	assert.EqualError(t, err, "a version key is required to version the values of the source")
+}
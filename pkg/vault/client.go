@@ -0,0 +1,256 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+const (
+	AuthMethodKubernetes string = "kubernetes"
+	AuthMethodAppRole    string = "approle"
+	AuthMethodToken      string = "token"
+)
+
+// VaultClient reads secrets from the KV secrets engines of Vault. It logs in with the configured
+// auth method and renews the token before its lease expires, or logs in again if that fails.
+type VaultClient struct {
+	client *api.Client
+	ctx    context.Context
+	cfg    config.Config
+	now    func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	renewable bool
+	// renewAt is the time after which the token is renewed, expiresAt the end of its lease.
+	// Both are zero for tokens that never expire.
+	renewAt   time.Time
+	expiresAt time.Time
+}
+
+type Option func(*VaultClient)
+
+func WithContext(ctx context.Context) Option {
+	return func(v *VaultClient) {
+		v.ctx = ctx
+	}
+}
+
+func NewClient(cfg config.Config, opts ...Option) (*VaultClient, error) {
+	vc := &VaultClient{cfg: cfg, ctx: context.Background(), now: time.Now}
+
+	for _, opt := range opts {
+		opt(vc)
+	}
+
+	vaultCfg := api.DefaultConfig()
+	vaultCfg.Address = cfg.VaultAddress
+	c, err := api.NewClient(vaultCfg)
+	if err != nil {
+		return nil, err
+	}
+	// the token is only set after the login
+	c.ClearToken()
+	if cfg.VaultNamespace != "" {
+		c.SetNamespace(cfg.VaultNamespace)
+	}
+	vc.client = c
+	return vc, nil
+}
+
+// Secret is the data of a KV secret together with its version. KV version 1 secrets
+// aren't versioned, their version is empty.
+type Secret struct {
+	Data    map[string]interface{}
+	Version string
+}
+
+// GetSecret reads the secret at the path, for example secret/my-app. The version can only be
+// set for secrets of a KV version 2 engine, it defaults to the latest version.
+func (vc *VaultClient) GetSecret(path string, version string) (*Secret, error) {
+	err := vc.authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	path = strings.Trim(path, "/")
+	mount, kvVersion := vc.mount(path)
+
+	var secret *Secret
+	if kvVersion != "2" {
+		if version != "" {
+			return nil, fmt.Errorf("secret %s is stored in a KV version 1 engine that doesn't support versions", path)
+		}
+		secret, err = vc.getSecretV1(path)
+	} else {
+		secret, err = vc.getSecretV2(mount, path, version)
+	}
+
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+		// the token might have been revoked, login again on the next read
+		vc.resetToken()
+	}
+	return secret, err
+}
+
+func (vc *VaultClient) getSecretV1(path string) (*Secret, error) {
+	secret, err := vc.client.Logical().ReadWithContext(vc.ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret %s not found", path)
+	}
+
+	return &Secret{Data: secret.Data}, nil
+}
+
+func (vc *VaultClient) getSecretV2(mount string, path string, version string) (*Secret, error) {
+	var query map[string][]string
+	if version != "" {
+		query = map[string][]string{"version": {version}}
+	}
+	dataPath := mount + "data/" + strings.TrimPrefix(path, mount)
+
+	secret, err := vc.client.Logical().ReadWithDataWithContext(vc.ctx, dataPath, query)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret %s not found", path)
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		// the data of deleted or destroyed versions is null
+		return nil, fmt.Errorf("secret %s not found", path)
+	}
+
+	result := &Secret{Data: data}
+	if metadata, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		result.Version = fmt.Sprint(metadata["version"])
+	}
+	return result, nil
+}
+
+// mount returns the mount path, with a trailing slash, and the KV version of the secrets engine the
+// path belongs to. It falls back to KV version 1 if the mount can't be looked up, same as the Vault CLI.
+func (vc *VaultClient) mount(path string) (string, string) {
+	secret, err := vc.client.Logical().ReadWithContext(vc.ctx, "sys/internal/ui/mounts/"+path)
+	if err != nil || secret == nil || secret.Data == nil {
+		return "", "1"
+	}
+
+	mount, _ := secret.Data["path"].(string)
+	version := "1"
+	if options, ok := secret.Data["options"].(map[string]interface{}); ok && options["version"] != nil {
+		version = fmt.Sprint(options["version"])
+	}
+	return mount, version
+}
+
+// authenticate logs in to Vault if there is no valid token and renews the token once two thirds
+// of its lease passed. A token that can't be renewed anymore is replaced by logging in again.
+func (vc *VaultClient) authenticate() error {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	now := vc.now()
+	if vc.token != "" && (vc.renewAt.IsZero() || now.Before(vc.renewAt)) {
+		return nil
+	}
+
+	if vc.token != "" && vc.renewable && now.Before(vc.expiresAt) {
+		secret, err := vc.client.Auth().Token().RenewSelfWithContext(vc.ctx, 0)
+		if err == nil {
+			return vc.setToken(vc.token, secret)
+		}
+	}
+
+	return vc.login()
+}
+
+func (vc *VaultClient) login() error {
+	var (
+		secret *api.Secret
+		err    error
+	)
+	switch vc.cfg.VaultAuthMethod {
+	case AuthMethodToken:
+		vc.client.SetToken(vc.cfg.VaultToken)
+		secret, err = vc.client.Auth().Token().LookupSelfWithContext(vc.ctx)
+		if err != nil {
+			vc.client.ClearToken()
+			return fmt.Errorf("failed to lookup Vault token. Error:%w", err)
+		}
+		return vc.setToken(vc.cfg.VaultToken, secret)
+	case AuthMethodKubernetes:
+		jwt, err := os.ReadFile(vc.cfg.VaultServiceAccountTokenPath)
+		if err != nil {
+			return fmt.Errorf("failed to read service account token. Error:%w", err)
+		}
+		secret, err = vc.client.Logical().WriteWithContext(vc.ctx, fmt.Sprintf("auth/%s/login", vc.cfg.VaultKubernetesMountPath), map[string]interface{}{
+			"role": vc.cfg.VaultRole,
+			"jwt":  string(jwt),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to login to Vault with Kubernetes auth. Error:%w", err)
+		}
+	case AuthMethodAppRole:
+		secret, err = vc.client.Logical().WriteWithContext(vc.ctx, fmt.Sprintf("auth/%s/login", vc.cfg.VaultAppRoleMountPath), map[string]interface{}{
+			"role_id":   vc.cfg.VaultAppRoleID,
+			"secret_id": vc.cfg.VaultAppRoleSecretID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to login to Vault with AppRole auth. Error:%w", err)
+		}
+	default:
+		return fmt.Errorf("unknown Vault auth method %s", vc.cfg.VaultAuthMethod)
+	}
+
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("login to Vault with %s auth didn't return a token", vc.cfg.VaultAuthMethod)
+	}
+	return vc.setToken(secret.Auth.ClientToken, secret)
+}
+
+func (vc *VaultClient) resetToken() {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	vc.token = ""
+	vc.client.ClearToken()
+}
+
+// setToken uses the token for the following requests and records when its lease has to be renewed.
+func (vc *VaultClient) setToken(token string, secret *api.Secret) error {
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return err
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return err
+	}
+
+	vc.client.SetToken(token)
+	vc.token = token
+	vc.renewable = renewable
+	vc.renewAt = time.Time{}
+	vc.expiresAt = time.Time{}
+	if ttl > 0 {
+		now := vc.now()
+		vc.renewAt = now.Add(ttl * 2 / 3)
+		vc.expiresAt = now.Add(ttl)
+	}
+	return nil
+}
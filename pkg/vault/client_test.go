@@ -0,0 +1,285 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fr123k/github-operator/pkg/config"
+)
+
+// fakeVault is an in-process stand-in for the Vault HTTP API with a KV version 2 engine
+// mounted at secret/ and a KV version 1 engine mounted at kv/.
+type fakeVault struct {
+	mu       sync.Mutex
+	tokens   map[string]bool
+	logins   map[string]int
+	renewals int
+	// ttl and renewable of the tokens returned by the logins
+	ttl       int
+	renewable bool
+
+	kv1 map[string]map[string]interface{}
+	kv2 map[string][]map[string]interface{}
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{
+		tokens:    map[string]bool{"root": true},
+		logins:    map[string]int{},
+		ttl:       3600,
+		renewable: true,
+		kv1:       map[string]map[string]interface{}{},
+		kv2:       map[string][]map[string]interface{}{},
+	}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case path == "auth/kubernetes/login":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role"] != "github-operator" || body["jwt"] != "service-account-token" {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		f.login(w, "kubernetes")
+		return
+	case path == "auth/approle/login":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+		f.login(w, "approle")
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	if !f.tokens[token] {
+		writeError(w, http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case path == "auth/token/lookup-self":
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"ttl": 0, "renewable": false}})
+	case path == "auth/token/renew-self":
+		f.renewals++
+		writeJSON(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": f.ttl, "renewable": f.renewable}})
+	case strings.HasPrefix(path, "sys/internal/ui/mounts/secret/"):
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"path": "secret/", "type": "kv", "options": map[string]interface{}{"version": "2"}}})
+	case strings.HasPrefix(path, "sys/internal/ui/mounts/kv/"):
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"path": "kv/", "type": "kv", "options": nil}})
+	case strings.HasPrefix(path, "secret/data/"):
+		versions := f.kv2[strings.TrimPrefix(path, "secret/data/")]
+		version := len(versions)
+		if v := r.URL.Query().Get("version"); v != "" {
+			version, _ = strconv.Atoi(v)
+		}
+		if version < 1 || version > len(versions) {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+			"data":     versions[version-1],
+			"metadata": map[string]interface{}{"version": version},
+		}})
+	case strings.HasPrefix(path, "kv/"):
+		data, ok := f.kv1[strings.TrimPrefix(path, "kv/")]
+		if !ok {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": data, "lease_duration": 2764800})
+	default:
+		writeError(w, http.StatusNotFound)
+	}
+}
+
+func (f *fakeVault) login(w http.ResponseWriter, method string) {
+	f.logins[method]++
+	token := method + "-token-" + strconv.Itoa(f.logins[method])
+	f.tokens[token] = true
+	writeJSON(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": f.ttl, "renewable": f.renewable}})
+}
+
+func (f *fakeVault) revoke() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = map[string]bool{}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{http.StatusText(status)}})
+}
+
+func newTestClient(t *testing.T, fake *fakeVault, cfg config.Config) *VaultClient {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	cfg.VaultAddress = server.URL
+	vc, err := NewClient(cfg, WithContext(context.Background()))
+	assert.NoError(t, err)
+	return vc
+}
+
+func tokenConfig() config.Config {
+	return config.Config{VaultAuthMethod: AuthMethodToken, VaultToken: "root"}
+}
+
+func TestGetSecretKVv2(t *testing.T) {
+	fake := newFakeVault()
+	fake.kv2["team/app"] = []map[string]interface{}{
+		{"password": "old"},
+		{"password": "new"},
+	}
+	vc := newTestClient(t, fake, tokenConfig())
+
+	secret, err := vc.GetSecret("secret/team/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "new"}, secret.Data)
+	assert.Equal(t, "2", secret.Version)
+
+	secret, err = vc.GetSecret("secret/team/app", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "old"}, secret.Data)
+	assert.Equal(t, "1", secret.Version)
+
+	_, err = vc.GetSecret("secret/team/missing", "")
+	assert.Error(t, err)
+}
+
+func TestGetSecretKVv1(t *testing.T) {
+	fake := newFakeVault()
+	fake.kv1["app"] = map[string]interface{}{"password": "value"}
+	vc := newTestClient(t, fake, tokenConfig())
+
+	secret, err := vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"password": "value"}, secret.Data)
+	assert.Empty(t, secret.Version)
+
+	_, err = vc.GetSecret("kv/app", "1")
+	assert.EqualError(t, err, "secret kv/app is stored in a KV version 1 engine that doesn't support versions")
+}
+
+func TestKubernetesAuth(t *testing.T) {
+	fake := newFakeVault()
+	fake.kv1["app"] = map[string]interface{}{"password": "value"}
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("service-account-token"), 0o600))
+	vc := newTestClient(t, fake, config.Config{
+		VaultAuthMethod:              AuthMethodKubernetes,
+		VaultRole:                    "github-operator",
+		VaultKubernetesMountPath:     "kubernetes",
+		VaultServiceAccountTokenPath: tokenPath,
+	})
+
+	_, err := vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	_, err = vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, fake.logins["kubernetes"])
+}
+
+func TestAppRoleAuth(t *testing.T) {
+	fake := newFakeVault()
+	fake.kv1["app"] = map[string]interface{}{"password": "value"}
+	vc := newTestClient(t, fake, config.Config{
+		VaultAuthMethod:       AuthMethodAppRole,
+		VaultAppRoleMountPath: "approle",
+		VaultAppRoleID:        "role",
+		VaultAppRoleSecretID:  "secret",
+	})
+
+	_, err := vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fake.logins["approle"])
+
+	vc.cfg.VaultAppRoleSecretID = "wrong"
+	vc.resetToken()
+	_, err = vc.GetSecret("kv/app", "")
+	assert.Error(t, err)
+}
+
+func TestTokenRenewal(t *testing.T) {
+	fake := newFakeVault()
+	fake.kv1["app"] = map[string]interface{}{"password": "value"}
+	vc := newTestClient(t, fake, config.Config{
+		VaultAuthMethod:       AuthMethodAppRole,
+		VaultAppRoleMountPath: "approle",
+		VaultAppRoleID:        "role",
+		VaultAppRoleSecretID:  "secret",
+	})
+	now := time.Now()
+	vc.now = func() time.Time { return now }
+
+	_, err := vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+
+	// the token is renewed once two thirds of its lease passed
+	now = now.Add(30 * time.Minute)
+	_, err = vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fake.renewals)
+
+	now = now.Add(15 * time.Minute)
+	_, err = vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fake.renewals)
+	assert.Equal(t, 1, fake.logins["approle"])
+
+	// an expired token is replaced by logging in again
+	now = now.Add(2 * time.Hour)
+	_, err = vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fake.renewals)
+	assert.Equal(t, 2, fake.logins["approle"])
+}
+
+func TestRevokedTokenLogsInAgain(t *testing.T) {
+	fake := newFakeVault()
+	fake.kv1["app"] = map[string]interface{}{"password": "value"}
+	vc := newTestClient(t, fake, config.Config{
+		VaultAuthMethod:       AuthMethodAppRole,
+		VaultAppRoleMountPath: "approle",
+		VaultAppRoleID:        "role",
+		VaultAppRoleSecretID:  "secret",
+	})
+
+	_, err := vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+
+	fake.revoke()
+	_, err = vc.GetSecret("kv/app", "")
+	assert.Error(t, err)
+
+	_, err = vc.GetSecret("kv/app", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fake.logins["approle"])
+}